@@ -0,0 +1,376 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
+)
+
+// ProgramStart is the address the assembled ROM is loaded at
+const ProgramStart = 0x200
+
+/*
+statement is a single parsed line of assembly
+*/
+type statement struct {
+	line     int
+	labels   []string
+	op       string
+	operands []string
+}
+
+/*
+Assembler turns chip-8 assembly into a ROM.
+
+The syntax uses Cowgod's mnemonics
+
+	; comments start with a semicolon
+	:const SPEED 2          ; constants
+	start:                  ; labels. ": start" works too
+	    LD V0, SPEED
+	    LD I, sprite
+	    DRW V0, V1, 1
+	    JP start
+	:org 0x300              ; continue assembling at an address
+	sprite:
+	    db 0x80, 0b01000000 ; data bytes. dw for 16 bit words
+
+Operands can add or subtract labels, constants and numbers, like sprite+1
+
+Origin is the address the first instruction is assembled at and
+FirstLine is the line number of the first line of source.
+Both can be changed when the source is part of something bigger, like
+an inline asm block in a smol program.
+ResolveRegister, when set, is asked for operands that are not V0 through VF
+
+Relocations holds the offset in the image of every instruction whose address
+operand points at a label. Adding a different origin to the address of those
+instructions moves the program without assembling it again
+*/
+type Assembler struct {
+	Origin          int
+	FirstLine       int
+	ResolveRegister func(name string) (int, bool)
+	Relocations     []int
+	filename        string
+	symbols         map[string]int
+	labels          map[string]bool
+	relocatable     bool
+	statements      []statement
+	image           []byte
+	written         []bool
+	pc              int
+}
+
+// NewAssembler creates an assembler for the given file
+func NewAssembler(filename string) *Assembler {
+	a := new(Assembler)
+	a.filename = filename
+	a.symbols = make(map[string]int)
+	a.labels = make(map[string]bool)
+	a.Origin = ProgramStart
+	a.FirstLine = 1
+	return a
+}
+
+/*
+Assemble is a shorthand for creating an assembler and
+assembling source with it
+*/
+func Assemble(filename string, source string) ([]byte, error) {
+	return NewAssembler(filename).Assemble(source)
+}
+
+/*
+Assemble turns source into a ROM image starting at Origin.
+
+The first pass works out the address of every label,
+the second pass encodes the instructions and data
+*/
+func (a *Assembler) Assemble(source string) ([]byte, error) {
+	a.statements = parseLines(source)
+
+	a.pc = a.Origin
+	for _, s := range a.statements {
+		if err := a.layout(s); err != nil {
+			return nil, a.errorAt(s, err)
+		}
+	}
+
+	a.pc = a.Origin
+	a.image = []byte{}
+	a.written = []bool{}
+	for _, s := range a.statements {
+		if err := a.emit(s); err != nil {
+			return nil, a.errorAt(s, err)
+		}
+	}
+
+	return a.image, nil
+}
+
+// errorAt turns err into a diagnostic pointing at the line of s
+func (a *Assembler) errorAt(s statement, err error) error {
+	return errors.AssemblyError(err.Error()).At(diag.Pos{File: a.filename, Line: s.line + a.FirstLine - 1})
+}
+
+// parseLines splits source into statements, dropping comments and empty lines
+func parseLines(source string) []statement {
+	statements := []statement{}
+	for lineNumber, line := range strings.Split(source, "\n") {
+		if i := strings.Index(line, ";"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		s := statement{line: lineNumber + 1}
+		for {
+			if strings.HasPrefix(line, ": ") {
+				//octo style ": label"
+				fields := strings.Fields(line[2:])
+				if len(fields) == 0 {
+					break
+				}
+				s.labels = append(s.labels, fields[0])
+				line = strings.TrimSpace(strings.TrimSpace(line[2:])[len(fields[0]):])
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) > 0 && strings.HasSuffix(fields[0], ":") && !strings.HasPrefix(fields[0], ":") {
+				s.labels = append(s.labels, strings.TrimSuffix(fields[0], ":"))
+				line = strings.TrimSpace(line[len(fields[0]):])
+				continue
+			}
+			break
+		}
+
+		if line != "" {
+			fields := strings.Fields(line)
+			s.op = strings.ToUpper(fields[0])
+			rest := strings.TrimSpace(line[len(fields[0]):])
+			if rest != "" {
+				for _, operand := range strings.Split(rest, ",") {
+					s.operands = append(s.operands, strings.TrimSpace(operand))
+				}
+			}
+			//":const NAME VALUE" has no comma between its operands
+			if s.op == ":CONST" && len(s.operands) == 1 {
+				s.operands = strings.Fields(s.operands[0])
+			}
+		}
+
+		if len(s.labels) > 0 || s.op != "" {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
+
+/*
+layout is the first pass. It defines labels and constants
+and moves the program counter past the statement
+*/
+func (a *Assembler) layout(s statement) error {
+	for _, label := range s.labels {
+		if _, ok := a.symbols[label]; ok {
+			return fmt.Errorf("%s is defined twice", label)
+		}
+		a.symbols[label] = a.pc
+		a.labels[label] = true
+	}
+
+	switch s.op {
+	case "":
+	case ":CONST":
+		if len(s.operands) != 2 {
+			return fmt.Errorf(":const takes a name and a value")
+		}
+		if _, ok := a.symbols[s.operands[0]]; ok {
+			return fmt.Errorf("%s is defined twice", s.operands[0])
+		}
+		value, err := a.value(s.operands[1])
+		if err != nil {
+			return err
+		}
+		a.symbols[s.operands[0]] = value
+	case ":ORG":
+		return a.org(s)
+	case "DB", ":BYTE":
+		a.pc += len(s.operands)
+	case "DW":
+		a.pc += 2 * len(s.operands)
+	default:
+		a.pc += 2
+	}
+	return nil
+}
+
+func (a *Assembler) org(s statement) error {
+	if len(s.operands) != 1 {
+		return fmt.Errorf(":org takes a single address")
+	}
+	addr, err := a.value(s.operands[0])
+	if err != nil {
+		return err
+	}
+	if addr < a.Origin || addr > 0xFFF {
+		return fmt.Errorf(":org address 0x%X is outside of program space", addr)
+	}
+	a.pc = addr
+	return nil
+}
+
+// emit is the second pass. It writes the bytes of the statement into the image
+func (a *Assembler) emit(s statement) error {
+	switch s.op {
+	case "", ":CONST":
+		return nil
+	case ":ORG":
+		return a.org(s)
+	case "DB", ":BYTE":
+		for _, operand := range s.operands {
+			value, err := a.byteValue(operand)
+			if err != nil {
+				return err
+			}
+			if err := a.write([]byte{byte(value)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "DW":
+		for _, operand := range s.operands {
+			value, err := a.value(operand)
+			if err != nil {
+				return err
+			}
+			if value < 0 || value > 0xFFFF {
+				return fmt.Errorf("%s does not fit in a word", operand)
+			}
+			if err := a.write([]byte{byte(value >> 8), byte(value)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	a.relocatable = false
+	opcode, err := a.encode(s.op, s.operands)
+	if err != nil {
+		return err
+	}
+	if a.relocatable {
+		a.Relocations = append(a.Relocations, a.pc-a.Origin)
+	}
+	return a.write(opcode.Bytes())
+}
+
+// write places bytes at the program counter, growing the image if needed
+func (a *Assembler) write(data []byte) error {
+	for _, b := range data {
+		offset := a.pc - a.Origin
+		if a.pc > 0xFFF {
+			return fmt.Errorf("program does not fit in memory")
+		}
+		for len(a.image) <= offset {
+			a.image = append(a.image, 0)
+			a.written = append(a.written, false)
+		}
+		if a.written[offset] {
+			return fmt.Errorf("0x%03X is written twice", a.pc)
+		}
+		a.image[offset] = b
+		a.written[offset] = true
+		a.pc++
+	}
+	return nil
+}
+
+/*
+value resolves an operand to a number.
+Operands are numbers, labels and constants added or subtracted from each other
+*/
+func (a *Assembler) value(operand string) (int, error) {
+	value, _, err := a.valueWithLabels(operand)
+	return value, err
+}
+
+/*
+valueWithLabels resolves an operand like value does.
+It also counts the labels in it, subtracted labels count as -1.
+An operand with a count of 1 is an address inside the program
+*/
+func (a *Assembler) valueWithLabels(operand string) (int, int, error) {
+	operand = strings.ReplaceAll(operand, " ", "")
+	if operand == "" {
+		return 0, 0, fmt.Errorf("expected a value")
+	}
+
+	total := 0
+	labels := 0
+	sign := 1
+	term := ""
+	flush := func() error {
+		if term == "" {
+			return fmt.Errorf("invalid expression %s", operand)
+		}
+		value, err := a.term(term)
+		if err != nil {
+			return err
+		}
+		if a.labels[term] {
+			labels += sign
+		}
+		total += sign * value
+		term = ""
+		return nil
+	}
+
+	for i, char := range operand {
+		if (char == '+' || char == '-') && i > 0 {
+			if err := flush(); err != nil {
+				return 0, 0, err
+			}
+			sign = 1
+			if char == '-' {
+				sign = -1
+			}
+			continue
+		}
+		term += string(char)
+	}
+	if err := flush(); err != nil {
+		return 0, 0, err
+	}
+	return total, labels, nil
+}
+
+func (a *Assembler) term(term string) (int, error) {
+	if value, ok := a.symbols[term]; ok {
+		return value, nil
+	}
+	if value, err := strconv.ParseInt(term, 0, 32); err == nil {
+		return int(value), nil
+	}
+	if strings.HasPrefix(term, "#") || strings.HasPrefix(term, "$") {
+		if value, err := strconv.ParseInt(term[1:], 16, 32); err == nil {
+			return int(value), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown symbol %s", term)
+}
+
+// byteValue resolves an operand that has to fit in a byte. negative values are stored as two's complement
+func (a *Assembler) byteValue(operand string) (int, error) {
+	value, err := a.value(operand)
+	if err != nil {
+		return 0, err
+	}
+	if value < -128 || value > 0xFF {
+		return 0, fmt.Errorf("%s does not fit in a byte", operand)
+	}
+	return value & 0xFF, nil
+}
@@ -0,0 +1,95 @@
+package asm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fabulousduck/smol/disasm"
+	"github.com/fabulousduck/smol/vm/chip8"
+)
+
+func TestAssemble(T *testing.T) {
+	source := `
+	; draws a single pixel at SPEED, SPEED and halts
+	:const SPEED 2
+	start:
+	    LD V0, SPEED
+	    LD V1, SPEED
+	    LD I, sprite
+	    DRW V0, V1, 1
+	end: JP end
+	:org 0x300
+	: sprite
+	    db 0x80, 0b01000000
+	    dw start+2
+	`
+	rom, err := Assemble("test.s", source)
+	if err != nil {
+		T.Logf("\nTestAssemble | %s", err.Error())
+		T.FailNow()
+	}
+
+	expected := []byte{0x60, 0x02, 0x61, 0x02, 0xA3, 0x00, 0xD0, 0x11, 0x12, 0x08}
+	if len(rom) != 0x100+4 || !bytes.Equal(rom[:len(expected)], expected) {
+		T.Logf("\nTestAssemble | got % X", rom[:len(expected)])
+		T.Fail()
+	}
+	if !bytes.Equal(rom[0x100:], []byte{0x80, 0x40, 0x02, 0x02}) {
+		T.Logf("\nTestAssemble | data at 0x300 is % X", rom[0x100:])
+		T.Fail()
+	}
+
+	m := chip8.NewMachine()
+	m.LoadROM(rom)
+	m.Run(100)
+	if !m.Pixel(2, 2) {
+		T.Logf("\nTestAssemble | pixel at 2, 2 was not drawn\n%s", m.ScreenString())
+		T.Fail()
+	}
+}
+
+//every mnemonic the disassembler prints has to assemble back into the same opcode
+func TestDecodeRoundTrip(T *testing.T) {
+	opcodes := []uint16{
+		0x00E0, 0x00EE, 0x0123, 0x120C, 0x2ABC, 0x330A, 0x4CFF, 0x5120, 0x630A, 0x7001,
+		0x8E00, 0x8121, 0x8122, 0x8123, 0x8124, 0x8125, 0x8126, 0x8127, 0x812E, 0x9120,
+		0xAEA0, 0xB300, 0xC50F, 0xDED1, 0xE49E, 0xE4A1, 0xF207, 0xF20A, 0xF215, 0xF218,
+		0xF21E, 0xF229, 0xF233, 0xF255, 0xF265,
+	}
+	for _, opcode := range opcodes {
+		text, ok := disasm.Decode(opcode)
+		if !ok {
+			T.Logf("\nTestDecodeRoundTrip | %04X does not decode", opcode)
+			T.Fail()
+			continue
+		}
+		rom, err := Assemble("test.s", text)
+		if err != nil || len(rom) != 2 || uint16(rom[0])<<8|uint16(rom[1]) != opcode {
+			T.Logf("\nTestDecodeRoundTrip | %q assembled to % X (%v). expected %04X", text, rom, err, opcode)
+			T.Fail()
+		}
+	}
+}
+
+func TestAssembleErrors(T *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{"LD V0, 1\nFOO V1", "test.s:2: unknown instruction FOO"},
+		{"JP nowhere", "test.s:1: unknown symbol nowhere"},
+		{"LD V0, 0x100", "test.s:1: 0x100 does not fit in a byte"},
+		{"LD VG, 1", "test.s:1: expected a register (V0 through VF). got VG"},
+		{"a:\na:", "test.s:2: a is defined twice"},
+		{"CLS V0", "test.s:1: CLS takes 0 operands. got 1"},
+		{"CLS\n:org 0x200\nCLS", "test.s:3: 0x200 is written twice"},
+	}
+	for _, c := range cases {
+		_, err := Assemble("test.s", c.source)
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			T.Logf("\nTestAssembleErrors | %q gave %v. expected %q", c.source, err, c.expected)
+			T.Fail()
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fabulousduck/smol/opcode"
+)
+
+/*
+encode turns a single instruction into its opcode.
+Mnemonics and operand forms are the ones disasm.Decode prints
+*/
+func (a *Assembler) encode(op string, operands []string) (opcode.Opcode, error) {
+	e := encoder{a: a, op: op, operands: operands}
+
+	switch op {
+	case "CLS":
+		return opcode.ClearScreen(), e.count(0)
+	case "RET":
+		return opcode.Return(), e.count(0)
+	case "SYS":
+		return opcode.Sys(e.addr(0)), e.count(1)
+	case "CALL":
+		return opcode.Call(e.addr(0)), e.count(1)
+	case "JP":
+		if len(operands) == 2 {
+			if x, ok := e.isRegister(0); !ok || x != 0 {
+				return 0, fmt.Errorf("JP with two operands only jumps relative to V0")
+			}
+			return opcode.JumpV0(e.addr(1)), e.count(2)
+		}
+		return opcode.Jump(e.addr(0)), e.count(1)
+	case "SE", "SNE":
+		x := e.register(0)
+		if y, ok := e.isRegister(1); ok {
+			if op == "SE" {
+				return opcode.SkipRegistersEqual(x, y), e.count(2)
+			}
+			return opcode.SkipRegistersNotEqual(x, y), e.count(2)
+		}
+		if op == "SE" {
+			return opcode.SkipEqual(x, e.byte(1)), e.count(2)
+		}
+		return opcode.SkipNotEqual(x, e.byte(1)), e.count(2)
+	case "LD":
+		return e.load()
+	case "ADD":
+		if e.is(0, "I") {
+			return opcode.AddI(e.register(1)), e.count(2)
+		}
+		x := e.register(0)
+		if y, ok := e.isRegister(1); ok {
+			return opcode.AddRegister(x, y), e.count(2)
+		}
+		return opcode.AddByte(x, e.byte(1)), e.count(2)
+	case "OR", "AND", "XOR", "SUB", "SUBN":
+		encoders := map[string]func(int, int) opcode.Opcode{
+			"OR": opcode.Or, "AND": opcode.And, "XOR": opcode.Xor, "SUB": opcode.Sub, "SUBN": opcode.SubN,
+		}
+		return encoders[op](e.register(0), e.register(1)), e.count(2)
+	case "SHR", "SHL":
+		//the second register is optional, it defaults to the first one
+		x := e.register(0)
+		y := x
+		if len(operands) > 1 {
+			y = e.register(1)
+		}
+		if len(operands) > 2 {
+			return 0, fmt.Errorf("%s takes 1 or 2 operands. got %d", op, len(operands))
+		}
+		if op == "SHR" {
+			return opcode.ShiftRight(x, y), e.err
+		}
+		return opcode.ShiftLeft(x, y), e.err
+	case "RND":
+		return opcode.Random(e.register(0), e.byte(1)), e.count(2)
+	case "DRW":
+		return opcode.Draw(e.register(0), e.register(1), e.nibble(2)), e.count(3)
+	case "SKP":
+		return opcode.SkipKeyPressed(e.register(0)), e.count(1)
+	case "SKNP":
+		return opcode.SkipKeyNotPressed(e.register(0)), e.count(1)
+	}
+
+	return 0, fmt.Errorf("unknown instruction %s", op)
+}
+
+//load encodes the many forms of LD
+func (e *encoder) load() (opcode.Opcode, error) {
+	switch {
+	case e.is(0, "I"):
+		return opcode.LoadI(e.addr(1)), e.count(2)
+	case e.is(0, "DT"):
+		return opcode.SetDelay(e.register(1)), e.count(2)
+	case e.is(0, "ST"):
+		return opcode.SetSound(e.register(1)), e.count(2)
+	case e.is(0, "F"):
+		return opcode.LoadFont(e.register(1)), e.count(2)
+	case e.is(0, "B"):
+		return opcode.StoreBCD(e.register(1)), e.count(2)
+	case e.is(0, "[I]"):
+		return opcode.StoreRegisters(e.register(1)), e.count(2)
+	}
+
+	x := e.register(0)
+	switch {
+	case e.is(1, "DT"):
+		return opcode.LoadDelay(x), e.count(2)
+	case e.is(1, "K"):
+		return opcode.WaitKey(x), e.count(2)
+	case e.is(1, "[I]"):
+		return opcode.LoadRegisters(x), e.count(2)
+	}
+	if y, ok := e.isRegister(1); ok {
+		return opcode.LoadRegister(x, y), e.count(2)
+	}
+	return opcode.Load(x, e.byte(1)), e.count(2)
+}
+
+/*
+encoder reads the operands of a single instruction.
+Like the IR operandParser it keeps the first error it runs into
+so operands can be read without checking every one
+*/
+type encoder struct {
+	a        *Assembler
+	op       string
+	operands []string
+	err      error
+}
+
+func (e *encoder) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *encoder) operand(i int) (string, bool) {
+	if i >= len(e.operands) {
+		e.fail(fmt.Errorf("%s takes more operands. got %d", e.op, len(e.operands)))
+		return "", false
+	}
+	return e.operands[i], true
+}
+
+//count checks the amount of operands and returns the first error
+func (e *encoder) count(expected int) error {
+	if len(e.operands) != expected {
+		return fmt.Errorf("%s takes %d operands. got %d", e.op, expected, len(e.operands))
+	}
+	return e.err
+}
+
+//is reports whether operand i is the given keyword like I, DT or [I]
+func (e *encoder) is(i int, keyword string) bool {
+	return i < len(e.operands) && strings.EqualFold(e.operands[i], keyword)
+}
+
+func (e *encoder) isRegister(i int) (int, bool) {
+	if i >= len(e.operands) {
+		return 0, false
+	}
+	if x, ok := register(e.operands[i]); ok {
+		return x, true
+	}
+	if e.a.ResolveRegister != nil {
+		return e.a.ResolveRegister(e.operands[i])
+	}
+	return 0, false
+}
+
+func (e *encoder) register(i int) int {
+	operand, ok := e.operand(i)
+	if !ok {
+		return 0
+	}
+	x, ok := e.isRegister(i)
+	if !ok {
+		e.fail(fmt.Errorf("expected a register (V0 through VF). got %s", operand))
+	}
+	return x
+}
+
+func (e *encoder) value(i int, max int, kind string) (int, int) {
+	operand, ok := e.operand(i)
+	if !ok {
+		return 0, 0
+	}
+	value, labels, err := e.a.valueWithLabels(operand)
+	if err != nil {
+		e.fail(err)
+		return 0, 0
+	}
+	if value < 0 || value > max {
+		e.fail(fmt.Errorf("%s does not fit in %s", operand, kind))
+	}
+	return value, labels
+}
+
+//addr reads an address operand. addresses that point at a label are marked for relocation
+func (e *encoder) addr(i int) int {
+	value, labels := e.value(i, 0xFFF, "an address")
+	if labels == 1 {
+		e.a.relocatable = true
+	}
+	return value
+}
+
+func (e *encoder) nibble(i int) int {
+	value, _ := e.value(i, 0xF, "4 bits")
+	return value
+}
+
+func (e *encoder) byte(i int) int {
+	operand, ok := e.operand(i)
+	if !ok {
+		return 0
+	}
+	value, err := e.a.byteValue(operand)
+	if err != nil {
+		e.fail(err)
+	}
+	return value
+}
+
+//register parses a register operand written as V0 through VF
+func register(operand string) (int, bool) {
+	if len(operand) == 2 && (operand[0] == 'V' || operand[0] == 'v') {
+		if value, err := strconv.ParseUint(operand[1:], 16, 4); err == nil {
+			return int(value), true
+		}
+	}
+	return 0, false
+}
@@ -1,25 +1,124 @@
 package ast
 
 import (
-	"os"
-
-	"github.com/davecgh/go-spew/spew"
 	"github.com/fabulousduck/proto/src/types"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
 	"github.com/fabulousduck/smol/lexer"
 )
 
-//IfStatement is a conditional block that has an expression and a body
+/*
+IfStatement is a conditional block that has a condition and a body.
+Else is run when the condition does not hold, an elif is an Else that holds a single IfStatement
+*/
 type IfStatement struct {
-	Condition Node
+	Position
+	Condition *Condition
 	Body      []Node
+	Else      []Node
 }
 
 func (i IfStatement) GetNodeName() string {
 	return "IfStatement"
 }
 
+//WhileLoop is a block whose body runs for as long as its condition holds. whileNot(a, b) is a WhileLoop on a != b
+type WhileLoop struct {
+	Position
+	Condition *Condition
+	Body      []Node
+}
+
+func (w WhileLoop) GetNodeName() string {
+	return "whileLoop"
+}
+
+/*
+Condition compares Left to Right with Operator, one of < > == != <= >=.
+A condition without an Operator holds when Left is not zero
+*/
+type Condition struct {
+	Position
+	Left     Node
+	Operator string
+	Right    Node
+}
+
+func (c Condition) GetNodeName() string {
+	return "condition"
+}
+
+//EqBlock runs its body when Lhs equals Rhs
+type EqBlock struct {
+	Position
+	Lhs  Node
+	Rhs  Node
+	Body []Node
+}
+
+func (e EqBlock) GetNodeName() string {
+	return "eqBlock"
+}
+
+//Condition is the comparison the block makes
+func (e EqBlock) Condition() *Condition {
+	return &Condition{Position: e.Position, Left: e.Lhs, Operator: "==", Right: e.Rhs}
+}
+
+//NeqBlock runs its body when Lhs does not equal Rhs
+type NeqBlock struct {
+	Position
+	Lhs  Node
+	Rhs  Node
+	Body []Node
+}
+
+func (n NeqBlock) GetNodeName() string {
+	return "neqBlock"
+}
+
+//Condition is the comparison the block makes
+func (n NeqBlock) Condition() *Condition {
+	return &Condition{Position: n.Position, Left: n.Lhs, Operator: "!=", Right: n.Rhs}
+}
+
+//GtBlock runs its body when Lhs is greater than Rhs
+type GtBlock struct {
+	Position
+	Lhs  Node
+	Rhs  Node
+	Body []Node
+}
+
+func (g GtBlock) GetNodeName() string {
+	return "gtBlock"
+}
+
+//Condition is the comparison the block makes
+func (g GtBlock) Condition() *Condition {
+	return &Condition{Position: g.Position, Left: g.Lhs, Operator: ">", Right: g.Rhs}
+}
+
+//LtBlock runs its body when Lhs is less than Rhs
+type LtBlock struct {
+	Position
+	Lhs  Node
+	Rhs  Node
+	Body []Node
+}
+
+func (l LtBlock) GetNodeName() string {
+	return "ltBlock"
+}
+
+//Condition is the comparison the block makes
+func (l LtBlock) Condition() *Condition {
+	return &Condition{Position: l.Position, Left: l.Lhs, Operator: "<", Right: l.Rhs}
+}
+
 //PlotStatement is a statement that contains all info needed to draw a pixel to the screen
 type PlotStatement struct {
+	Position
 	X, Y Node
 }
 
@@ -29,6 +128,7 @@ func (p PlotStatement) GetNodeName() string {
 
 //FreeStatement is an instruction that frees a variable from the stack
 type FreeStatement struct {
+	Position
 	Variable Node
 }
 
@@ -36,7 +136,20 @@ func (r FreeStatement) GetNodeName() string {
 	return "freeStatement"
 }
 
+//AsmBlock is a block of chip-8 assembly that is put into the ROM as is
+//Line is the line the block starts on so errors in the assembly can point at the source
+type AsmBlock struct {
+	Position
+	Source string
+	Line   int
+}
+
+func (ab AsmBlock) GetNodeName() string {
+	return "asmBlock"
+}
+
 type DirectOperation struct {
+	Position
 	Variable  Node
 	Operation string
 }
@@ -48,10 +161,27 @@ func (do DirectOperation) GetNodeName() string {
 //Node is a wrapper interface that AST nodes can implement
 type Node interface {
 	GetNodeName() string //GetNodeName Gets the identifier of a AST node describing what it is
+	GetSpan() diag.Span  //GetSpan gives the part of the source the node was parsed from
+}
+
+//Position is embedded in every node to keep the part of the source it was parsed from.
+//Span starts at the first character of its first token and ends just past its last token
+type Position struct {
+	Span diag.Span
+}
+
+//GetSpan so every node that embeds a Position is valid on the node interface
+func (p Position) GetSpan() diag.Span {
+	return p.Span
+}
+
+func (p *Position) setSpan(span diag.Span) {
+	p.Span = span
 }
 
 //StringLit represents a string litteral
 type StringLit struct {
+	Position
 	Value string
 }
 
@@ -61,6 +191,7 @@ func (sl StringLit) GetNodeName() string {
 
 //BoolLit represents a boolean litteral being either "True" or "False"
 type BoolLit struct {
+	Position
 	Value string
 }
 
@@ -70,6 +201,7 @@ func (bm BoolLit) GetNodeName() string {
 
 //NumLit represents a numeric litteral.
 type NumLit struct {
+	Position
 	Value string
 }
 
@@ -79,6 +211,7 @@ func (nm NumLit) GetNodeName() string {
 
 //Eos is a special node in a switch statement that is called if defined when no cases match the given value
 type Eos struct {
+	Position
 	Body []Node
 }
 
@@ -88,6 +221,7 @@ func (eos Eos) GetNodeName() string {
 
 //SwitchCase is a block definiton that is run when the MatchValue is matched
 type SwitchCase struct {
+	Position
 	MatchValue Node
 	Body       []Node
 }
@@ -100,6 +234,7 @@ func (sc SwitchCase) GetNodeName() string {
 //if one matches, the body of that case will be executed.
 //if a EOS is defined within the body, the EOS body will be run if no case matches the matchvalue
 type SwitchStatement struct {
+	Position
 	MatchValue Node
 	Cases      []Node
 }
@@ -112,6 +247,7 @@ func (st SwitchStatement) GetNodeName() string {
 //These statVars are used when a variable is being referenced
 //where the Value is the name of the variable referenced
 type StatVar struct {
+	Position
 	Value string
 }
 
@@ -121,6 +257,7 @@ func (sv StatVar) GetNodeName() string {
 
 //Function is a standard function definition containing the name, parameters and body of the function
 type Function struct {
+	Position
 	Name   string
 	Params []string
 	Body   []Node
@@ -133,6 +270,7 @@ func (f Function) GetNodeName() string {
 //Variable is a construct used to create a new variable.
 //This is the struct that will be pushed to the stack
 type Variable struct {
+	Position
 	Name            string
 	Type            string
 	Value           Node
@@ -141,6 +279,7 @@ type Variable struct {
 
 //SetStatement is used when a value needs to be set to a variable. Instructions that could make use of this are SET
 type SetStatement struct {
+	Position
 	MHS Node
 	RHS Node
 }
@@ -151,6 +290,7 @@ func (ss SetStatement) GetNodeName() string {
 
 //Statement is a general statement container for all other statements that do not fall under math and logic for example MEM
 type Statement struct {
+	Position
 	LHS string
 	RHS Node
 }
@@ -165,6 +305,7 @@ func (v Variable) GetNodeName() string {
 
 //PrintCall specifies a call to the inbuilt print function
 type PrintCall struct {
+	Position
 	Printable Node
 }
 
@@ -174,6 +315,7 @@ func (pc PrintCall) GetNodeName() string {
 
 //FunctionCall specifies a function call and the arguments given
 type FunctionCall struct {
+	Position
 	Name string
 	Args []Node
 }
@@ -183,22 +325,46 @@ func (fc FunctionCall) GetNodeName() string {
 }
 
 //Parser contains the final AST and forms a base for all ast generating functions
+//syntax errors are added to Diagnostics
 type Parser struct {
 	Tokens         []lexer.Token
 	Ast            []Node
+	Diagnostics    *diag.List
 	Filename       string
 	TokensConsumed int
+	syntax         *syntaxRecorder
+	offset         int
+	//closed is set when the block being parsed was ended by end
+	closed bool
+	//inIf is set when the block being parsed is the body of an if or elif, which an else or elif can end
+	inIf bool
+}
+
+//syntaxError is used to unwind the parser to the statement it was parsing once an error has been reported
+type syntaxError struct {
+	line int
 }
 
 //NewParser returns a new Parser instance with the given file
 func NewParser(filename string, tokens []lexer.Token) *Parser {
 	p := new(Parser)
 	p.Filename = filename
+	p.Diagnostics = new(diag.List)
 	p.TokensConsumed = 0
 	p.Tokens = tokens
+	p.syntax = new(syntaxRecorder)
 	return p
 }
 
+//subParser creates a parser for the tokens that have not been consumed yet, like those of a block body
+func (p *Parser) subParser(tokens []lexer.Token) *Parser {
+	sub := NewParser(p.Filename, tokens)
+	sub.Diagnostics = p.Diagnostics
+	sub.syntax = p.syntax
+	sub.offset = p.offset + p.TokensConsumed
+	return sub
+}
+
 //Parse takes a set of tokens and generates an AST from them
 func (p *Parser) Parse(delim string) ([]Node, int) {
 	nodes := []Node{}
@@ -208,91 +374,370 @@ func (p *Parser) Parse(delim string) ([]Node, int) {
 			return nodes, p.TokensConsumed
 		}
 
-		switch p.currentToken().Type {
-		case "plot":
-			p.advance()
-			nodes = append(nodes, p.createPlot())
-		case "variable_type":
-			nodes = append(nodes, p.createVariable())
-		case "function_definition":
-			p.advance()
-			nodes = append(nodes, p.createFunction())
-		case "print":
-			p.advance()
-			nodes = append(nodes, p.createPrintCall())
-		case "set_variable":
-			p.advance()
-			nodes = append(nodes, p.createSetStatement())
-
-		case "if_statement":
-			p.advance()
-			nodes = append(nodes, p.createIfStatement())
-		case "close_block":
-			p.advance()
-			return nodes, p.TokensConsumed
-		//string and character loose can be either a function call or a direct operation on the variable such as a++s
-		case "string":
-			fallthrough
-		case "character":
-			//its either a function
-			if p.nextToken().Type == "left_parenthesis" {
-				nodes = append(nodes, p.createFunctionCall())
-				//or a direct operation
-			} else {
-				nodes = append(nodes, p.createDirectOperation())
-			}
-		case "free":
-			p.advance()
-			nodes = append(nodes, p.createFreeStatement())
-		case "switch":
-			p.advance()
-			nodes = append(nodes, p.createSwitchStatement())
-			p.advance()
-		case "case":
-			p.advance()
-			nodes = append(nodes, p.createSwitchCase())
-		case "end_of_switch":
-			p.advance()
-			nodes = append(nodes, p.createEOSStatement())
-		case "end_of_file":
+		node, end := p.parseStatement()
+		if end {
 			return nodes, p.TokensConsumed
-		default:
-			// spew.Dump(p.currentToken())
-			// errors.UnknownTypeError()
 		}
-
+		if node != nil {
+			nodes = append(nodes, node)
+		}
 	}
 	return nodes, p.TokensConsumed
 }
 
+/*
+parseStatement parses the statement at the current token.
+end is true when the current block ends.
+
+A statement with a syntax error becomes nil and the tokens
+up to the next line are skipped, so the statements after it are still checked
+*/
+func (p *Parser) parseStatement() (node Node, end bool) {
+	start := p.TokensConsumed
+	p.syntax.open(p.offset + start)
+	defer func() {
+		if positioned, ok := node.(interface{ setSpan(diag.Span) }); ok && p.TokensConsumed > start {
+			positioned.setSpan(p.spanOf(p.Tokens[start:min(p.TokensConsumed, len(p.Tokens))]))
+		}
+		p.syntax.close(node, p.offset+p.TokensConsumed)
+	}()
+	defer p.recoverStatement()
+
+	switch p.currentToken().Type {
+	case "plot":
+		p.advance()
+		return p.createPlot(), false
+	case "variable_type":
+		return p.createVariable(), false
+	case "function_definition":
+		p.advance()
+		return p.createFunction(), false
+	case "print":
+		p.advance()
+		return p.createPrintCall(), false
+	case "set_variable":
+		p.advance()
+		return p.createSetStatement(), false
+
+	case "if_statement":
+		p.advance()
+		return p.createIfStatement(), false
+	case "while_loop":
+		p.advance()
+		return p.createWhileLoop(), false
+	case "while_not_loop":
+		p.advance()
+		return p.createWhileNotLoop(), false
+	case "eq_block":
+		p.advance()
+		block := new(EqBlock)
+		block.Lhs, block.Rhs, block.Body = p.createComparisonBlock()
+		return block, false
+	case "neq_block":
+		p.advance()
+		block := new(NeqBlock)
+		block.Lhs, block.Rhs, block.Body = p.createComparisonBlock()
+		return block, false
+	case "gt_block":
+		p.advance()
+		block := new(GtBlock)
+		block.Lhs, block.Rhs, block.Body = p.createComparisonBlock()
+		return block, false
+	case "lt_block":
+		p.advance()
+		block := new(LtBlock)
+		block.Lhs, block.Rhs, block.Body = p.createComparisonBlock()
+		return block, false
+	case "asm_block":
+		p.advance()
+		return p.createAsmBlock(), false
+	case "close_block":
+		p.advance()
+		p.closed = true
+		return nil, true
+	//else and elif end the body of an if, the if reads them itself
+	case "else_statement", "elif_statement":
+		if p.inIf {
+			return nil, true
+		}
+		p.skipStrayElse()
+		return nil, false
+	//string and character loose can be either a function call or a direct operation on the variable such as a++s
+	case "string":
+		fallthrough
+	case "character":
+		//its either a function
+		if p.nextToken().Type == "left_parenthesis" {
+			return p.createFunctionCall(), false
+			//or a direct operation
+		} else {
+			return p.createDirectOperation(), false
+		}
+	case "free":
+		p.advance()
+		return p.createFreeStatement(), false
+	case "switch":
+		p.advance()
+		return p.createSwitchStatement(), false
+	case "case":
+		p.advance()
+		return p.createSwitchCase(), false
+	case "end_of_switch":
+		p.advance()
+		return p.createEOSStatement(), false
+	case "end_of_file":
+		return nil, true
+	default:
+		// spew.Dump(p.currentToken())
+		// errors.UnknownTypeError()
+		p.advance()
+	}
+	return nil, false
+}
+
+/*
+createIfStatement reads an if statement. It adheres to the following structure,
+where there can be any number of elifs and the else can be left out
+
+	if(<condition>):
+		<body>
+	elif(<condition>):
+		<body>
+	else:
+		<body>
+	end
+*/
 func (p *Parser) createIfStatement() *IfStatement {
 	ifStatement := new(IfStatement)
+	opening := p.keyword()
+
+	p.expectCurrent([]string{"left_parenthesis"})
+	p.advance()
+
+	ifStatement.Condition = p.createCondition()
+
+	p.expectCurrent([]string{"right_parenthesis"})
+	p.advance()
+
+	body, closed := p.createBlockBody(true)
+	ifStatement.Body = body
+	if closed {
+		return ifStatement
+	}
+
+	switch p.currentToken().Type {
+	case "elif_statement":
+		start := p.TokensConsumed
+		p.advance()
+		elif := p.createIfStatement()
+		elif.Span = p.spanOf(p.Tokens[start:p.TokensConsumed])
+		ifStatement.Else = []Node{elif}
+	case "else_statement":
+		p.advance()
+		opening = p.keyword()
+		ifStatement.Else, closed = p.createBlockBody(false)
+		p.expectBlockEnd(opening, closed)
+	default:
+		p.expectBlockEnd(opening, closed)
+	}
+	return ifStatement
+}
+
+func (p *Parser) createWhileLoop() *WhileLoop {
+	loop := new(WhileLoop)
+	opening := p.keyword()
+
+	p.expectCurrent([]string{"left_parenthesis"})
+	p.advance()
+
+	loop.Condition = p.createCondition()
+
+	p.expectCurrent([]string{"right_parenthesis"})
+	p.advance()
+
+	body, closed := p.createBlockBody(false)
+	loop.Body = body
+	p.expectBlockEnd(opening, closed)
+	return loop
+}
+
+//createWhileNotLoop reads whileNot(a, b), which loops until a equals b
+func (p *Parser) createWhileNotLoop() *WhileLoop {
+	loop := new(WhileLoop)
+	opening := p.keyword()
+	start := p.TokensConsumed
+
+	condition := new(Condition)
+	condition.Left, condition.Right = p.createOperandPair()
+	condition.Operator = "!="
+	condition.Span = p.spanOf(p.Tokens[start:p.TokensConsumed])
+	loop.Condition = condition
+
+	body, closed := p.createBlockBody(false)
+	loop.Body = body
+	p.expectBlockEnd(opening, closed)
+	return loop
+}
+
+//createComparisonBlock reads what follows eq, neq, gt and lt, (<value>, <value>): <body> end
+func (p *Parser) createComparisonBlock() (Node, Node, []Node) {
+	opening := p.keyword()
+	lhs, rhs := p.createOperandPair()
+	body, closed := p.createBlockBody(false)
+	p.expectBlockEnd(opening, closed)
+	return lhs, rhs, body
+}
 
+//createOperandPair reads two operands in parentheses, (<value>, <value>)
+func (p *Parser) createOperandPair() (Node, Node) {
 	p.expectCurrent([]string{"left_parenthesis"})
 	p.advance()
 
-	condition, _ := p.readExpressionUntil([]string{")"})
-	spew.Dump(condition)
-	ifStatement.Condition = condition
+	lhs := p.createOperand()
+
+	p.expectCurrent([]string{"comma"})
 	p.advance()
 
+	rhs := p.createOperand()
+
+	p.expectCurrent([]string{"right_parenthesis"})
+	p.advance()
+	return lhs, rhs
+}
+
+/*
+createBlockBody reads the body of a block, from the colon that opens it up to the end that closes it.
+The body of an if is also closed by an else or elif, inIf says if this is one.
+closed is set when it was end
+*/
+func (p *Parser) createBlockBody(inIf bool) (body []Node, closed bool) {
 	p.expectCurrent([]string{"double_dot"})
 	p.advance()
 
-	ifBodyParser := NewParser(p.Filename, p.Tokens[p.TokensConsumed:])
-	body, consumed := ifBodyParser.Parse("")
-	ifStatement.Body = body
+	bodyParser := p.subParser(p.Tokens[p.TokensConsumed:])
+	bodyParser.inIf = inIf
+	body, consumed := bodyParser.Parse("")
 	p.advanceN(consumed)
+	return body, bodyParser.closed
+}
 
-	spew.Dump(ifStatement)
+/*
+skipStrayElse reports an else or elif that does not follow the body of an if.
+Only the line it is on is skipped, so what was meant as its body is still parsed
+*/
+func (p *Parser) skipStrayElse() {
+	stray := p.currentToken()
+	span := p.tokenSpan(stray)
+	p.Diagnostics.Add(errors.ElseWithoutIfError(stray.Value).Between(span.Start, span.End))
+	for p.hasCurrent() && p.currentToken().Line == stray.Line {
+		p.advance()
+	}
+}
 
-	return ifStatement
+//keyword returns the keyword that was just read, the one a block is created for
+func (p *Parser) keyword() lexer.Token {
+	return p.tokenAt(p.TokensConsumed - 1)
+}
+
+/*
+expectBlockEnd fails at opening, the keyword that started the block,
+when the body of the block was not closed with end.
+That happens when the file ends first, or for an if when the file ends in its elif or else
+*/
+func (p *Parser) expectBlockEnd(opening lexer.Token, closed bool) {
+	if !closed {
+		p.fail(errors.UnclosedBlockError(opening.Value), opening)
+	}
+}
+
+//comparisons are the token types a condition can compare its sides with
+var comparisons = []string{"less_than", "greater_than", "comparison", "not_equal", "less_than_or_equal", "greater_than_or_equal"}
+
+/*
+createCondition reads a condition. It adheres to one of the following structures
+
+<value>
+<value> <comparison> <value>
+
+where a value is a number, a boolean, a variable or an expression of them like a + 1
+*/
+func (p *Parser) createCondition() *Condition {
+	condition := new(Condition)
+	start := p.TokensConsumed
+
+	condition.Left = p.createOperand()
+	if types.Contains(p.currentToken().Type, comparisons) {
+		condition.Operator = p.currentToken().Value
+		p.advance()
+		condition.Right = p.createOperand()
+	}
+
+	condition.Span = p.spanOf(p.Tokens[start:p.TokensConsumed])
+	return condition
+}
+
+//expressionTokens are the token types a side of a condition can be made of
+var expressionTokens = []string{"integer", "boolean_keyword", "character", "string", "plus", "dash", "star", "division", "exponent", "left_parenthesis", "right_parenthesis"}
+
+/*
+createOperand reads a side of a condition. A single number, boolean or variable becomes a litteral,
+anything longer an expression. The side ends at the first token that cannot be part of it,
+like a comparison, a comma or the parenthesis that closes the condition
+*/
+func (p *Parser) createOperand() Node {
+	p.expectCurrent([]string{"integer", "boolean_keyword", "character", "string", "left_parenthesis"})
+	start := p.TokensConsumed
+	depth := 0
+	for p.hasCurrent() && types.Contains(p.currentToken().Type, expressionTokens) {
+		if p.currentToken().Type == "left_parenthesis" {
+			depth++
+		}
+		if p.currentToken().Type == "right_parenthesis" {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		p.advance()
+	}
+
+	tokens := p.Tokens[start:p.TokensConsumed]
+	if len(tokens) == 1 {
+		p.TokensConsumed = start
+		p.expectCurrent([]string{"integer", "boolean_keyword", "character", "string"})
+		operand := p.createLit(p.currentToken())
+		p.advance()
+		return operand
+	}
+
+	expression := p.subParser(tokens).parseExpression()
+	expression.Span = p.spanOf(tokens)
+	return &expression
+}
+
+func (p *Parser) createAsmBlock() *AsmBlock {
+	ab := new(AsmBlock)
+	opening := p.keyword()
+
+	p.expectCurrent([]string{"double_dot"})
+	p.advance()
+
+	p.expectCurrent([]string{"asm_body"})
+	ab.Source = p.currentToken().Value
+	ab.Line = p.currentToken().Line
+	p.advance()
+
+	//the lexer puts everything up to the end in the body, so only the end of the file can come instead of it
+	p.expectBlockEnd(opening, p.currentToken().Type == "close_block")
+	p.advance()
+
+	return ab
 }
 
 func (p *Parser) createDirectOperation() *DirectOperation {
 	do := new(DirectOperation)
 
-	do.Variable = createLit(p.currentToken())
+	do.Variable = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"direct_variable_operation"})
@@ -308,7 +753,7 @@ func (p *Parser) createPrintCall() *PrintCall {
 	p.advance()
 
 	p.expectCurrent([]string{"character", "string", "integer", "string_litteral"})
-	pc.Printable = createLit(p.currentToken())
+	pc.Printable = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"right_parenthesis"})
@@ -323,14 +768,14 @@ func (p *Parser) createPlot() *PlotStatement {
 	p.advance()
 
 	p.expectCurrent([]string{"character", "string", "integer"})
-	ps.X = createLit(p.currentToken())
+	ps.X = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"comma"})
 	p.advance()
 
 	p.expectCurrent([]string{"character", "string", "integer"})
-	ps.Y = createLit(p.currentToken())
+	ps.Y = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"right_parenthesis"})
@@ -344,7 +789,7 @@ func (p *Parser) createFreeStatement() *FreeStatement {
 	r := new(FreeStatement)
 
 	p.expectCurrent([]string{"string", "character"})
-	r.Variable = createLit(p.currentToken())
+	r.Variable = p.createLit(p.currentToken())
 	p.advance()
 
 	return r
@@ -352,59 +797,47 @@ func (p *Parser) createFreeStatement() *FreeStatement {
 
 func (p *Parser) createEOSStatement() *Eos {
 	eos := new(Eos)
+	opening := p.keyword()
 
-	p.expectCurrent([]string{"double_dot"})
-	p.advance()
-
-	eosParser := NewParser(p.Filename, p.Tokens[p.TokensConsumed:])
-	body, consumed := eosParser.Parse("")
+	body, closed := p.createBlockBody(false)
 	eos.Body = body
-
-	p.advanceN(consumed)
+	p.expectBlockEnd(opening, closed)
 
 	return eos
 }
 
 func (p *Parser) createSwitchCase() *SwitchCase {
 	sc := new(SwitchCase)
+	opening := p.keyword()
 
 	p.expectCurrent([]string{"character", "string", "integer"})
-	sc.MatchValue = createLit(p.currentToken())
-	p.advance()
-
-	p.expectCurrent([]string{"double_dot"})
+	sc.MatchValue = p.createLit(p.currentToken())
 	p.advance()
 
-	switchParser := NewParser(p.Filename, p.Tokens[p.TokensConsumed:])
-	body, consumed := switchParser.Parse("")
+	body, closed := p.createBlockBody(false)
 	sc.Body = body
-
-	p.advanceN(consumed)
+	p.expectBlockEnd(opening, closed)
 
 	return sc
 }
 
 func (p *Parser) createSwitchStatement() *SwitchStatement {
 	st := new(SwitchStatement)
+	opening := p.keyword()
 
 	p.expectCurrent([]string{"left_parenthesis"})
 	p.advance()
 
 	p.expectCurrent([]string{"character", "string", "integer"})
-	st.MatchValue = createLit(p.currentToken())
+	st.MatchValue = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"right_parenthesis"})
 	p.advance()
 
-	p.expectCurrent([]string{"double_dot"})
-	p.advance()
-
-	switchParser := NewParser(p.Filename, p.Tokens[p.TokensConsumed:])
-
-	body, consumed := switchParser.Parse("")
+	body, closed := p.createBlockBody(false)
 	st.Cases = body
-	p.advanceN(consumed)
+	p.expectBlockEnd(opening, closed)
 
 	return st
 }
@@ -419,6 +852,12 @@ func (p *Parser) createFunctionCall() *FunctionCall {
 	p.expectCurrent([]string{"left_parenthesis"})
 	p.advance()
 
+	//a call without arguments
+	if p.currentToken().Type == "right_parenthesis" {
+		p.advance()
+		return fc
+	}
+
 	for currentToken := p.currentToken(); currentToken.Type != "right_parenthesis"; currentToken = p.currentToken() {
 		exprList, delimFound := p.readExpressionUntil([]string{",", ")"})
 		fc.Args = append(fc.Args, exprList)
@@ -429,7 +868,6 @@ func (p *Parser) createFunctionCall() *FunctionCall {
 		p.advance()
 		break
 	}
-	spew.Dump(fc)
 
 	return fc
 }
@@ -438,11 +876,11 @@ func (p *Parser) createSetStatement() *SetStatement {
 	ss := new(SetStatement)
 
 	p.expectCurrent([]string{"character", "string"})
-	ss.MHS = createLit(p.currentToken())
+	ss.MHS = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"integer", "character", "string"})
-	ss.RHS = createLit(p.currentToken())
+	ss.RHS = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"semicolon"})
@@ -457,7 +895,7 @@ func (p *Parser) createStatement(lhs string) *Statement {
 	s.LHS = lhs
 
 	p.expectCurrent([]string{"string", "character", "integer"})
-	s.RHS = createLit(p.currentToken())
+	s.RHS = p.createLit(p.currentToken())
 	p.advance()
 
 	p.expectCurrent([]string{"semicolon"})
@@ -469,6 +907,7 @@ func (p *Parser) createStatement(lhs string) *Statement {
 func (p *Parser) createFunction() *Function {
 
 	f := new(Function)
+	opening := p.keyword()
 
 	p.expectCurrent([]string{"string", "character"})
 	f.Name = p.currentToken().Value
@@ -491,14 +930,11 @@ func (p *Parser) createFunction() *Function {
 
 	}
 
-	p.advance()
-	p.expectCurrent([]string{"double_dot"})
 	p.advance()
 
-	functionParser := NewParser(p.Filename, p.Tokens[p.TokensConsumed:])
-	body, consumed := functionParser.Parse("")
+	body, closed := p.createBlockBody(false)
 	f.Body = body
-	p.advanceN(consumed)
+	p.expectBlockEnd(opening, closed)
 	return f
 }
 
@@ -507,7 +943,6 @@ createVariable reads tokens to create a variable
 It adheres to the following structure
 
 <type> <name> <value>
-
 */
 func (p *Parser) createVariable() *Variable {
 	variable := new(Variable)
@@ -523,26 +958,37 @@ func (p *Parser) createVariable() *Variable {
 	p.expectCurrent([]string{"equals"})
 	p.advance()
 	variable.ValueExpression = p.readExpression()
+
+	//single value assignments are also available as a plain node
+	//so later stages do not have to deal with an expression
+	if len(variable.ValueExpression.Tokens) == 1 {
+		variable.Value = p.createLit(variable.ValueExpression.Tokens[0])
+	}
 	return variable
 }
 
-func createLit(token lexer.Token) Node {
+func (p *Parser) createLit(token lexer.Token) Node {
+	span := p.tokenSpan(token)
 	switch token.Type {
 	case "integer":
 		nl := new(NumLit)
 		nl.Value = token.Value
+		nl.Span = span
 		return nl
 	case "boolean_keyword":
 		bl := new(BoolLit)
 		bl.Value = token.Value
+		bl.Span = span
 		return bl
 	case "string_litteral":
 		sl := new(StringLit)
 		sl.Value = token.Value
+		sl.Span = span
 		return sl
 	default:
 		sv := new(StatVar)
 		sv.Value = token.Value
+		sv.Span = span
 		return sv
 	}
 }
@@ -551,16 +997,93 @@ func (p *Parser) expectCurrent(expectedValues []string) {
 	currentToken := p.currentToken()
 
 	if !types.Contains(currentToken.Type, expectedValues) {
-		lexer.ThrowSemanticError(&currentToken, expectedValues, p.Filename)
-		os.Exit(65)
+		p.fail(errors.UnexpectedTokenError(expectedValues, currentToken.Type), currentToken)
 	}
 }
 
 func (p *Parser) expectNext(expectedValues []string) {
 	nextToken := p.nextToken()
 	if !types.Contains(nextToken.Type, expectedValues) {
-		lexer.ThrowSemanticError(&nextToken, expectedValues, p.Filename)
-		os.Exit(65)
+		p.fail(errors.UnexpectedTokenError(expectedValues, nextToken.Type), nextToken)
+	}
+}
+
+//fail reports d at token and unwinds to the statement that is being parsed
+func (p *Parser) fail(d diag.Diagnostic, token lexer.Token) {
+	span := p.tokenSpan(token)
+	p.Diagnostics.Add(d.Between(span.Start, span.End))
+	panic(syntaxError{token.Line})
+}
+
+//tokenSpan gives the part of the source token was read from
+func (p *Parser) tokenSpan(token lexer.Token) diag.Span {
+	start := diag.Pos{File: p.Filename, Line: token.Line, Col: token.Col + 1}
+	end := start
+	end.Col += len(token.Value)
+	if token.Type == "string_litteral" {
+		//the quotes are not part of the value
+		end.Col += 2
+	}
+	return diag.Span{Start: start, End: end}
+}
+
+//spanOf gives the part of the source from the first of tokens up to the end of the last one
+func (p *Parser) spanOf(tokens []lexer.Token) diag.Span {
+	if len(tokens) == 0 {
+		return diag.Span{}
+	}
+	return diag.Span{Start: p.tokenSpan(tokens[0]).Start, End: p.tokenSpan(tokens[len(tokens)-1]).End}
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+/*
+recoverStatement stops the unwinding started by fail.
+The rest of the line the error is on is skipped.
+When that line opens a block the whole block is skipped as well,
+otherwise its end would close the block around it
+*/
+func (p *Parser) recoverStatement() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err, ok := r.(syntaxError)
+	if !ok {
+		panic(r)
+	}
+
+	opensBlock := false
+	for p.hasCurrent() && p.currentToken().Line <= err.line {
+		opensBlock = p.currentToken().Type == "double_dot"
+		p.advance()
+	}
+	if !opensBlock {
+		return
+	}
+
+	depth := 1
+	continued := false
+	for p.hasCurrent() && depth > 0 {
+		token := p.currentToken()
+		p.advance()
+		switch {
+		case token.Type == "close_block":
+			depth--
+		case token.Type == "else_statement" || token.Type == "elif_statement":
+			//the block goes on after else and elif, their colon does not open a new one
+			continued = true
+		case token.Type == "double_dot" && (!p.hasCurrent() || p.currentToken().Line != token.Line):
+			if !continued {
+				depth++
+			}
+			continued = false
+		}
 	}
 }
 
@@ -569,12 +1092,30 @@ func (p *Parser) nextExists() bool {
 	return p.TokensConsumed+1 < len(p.Tokens)
 }
 
+func (p *Parser) hasCurrent() bool {
+	return p.TokensConsumed < len(p.Tokens)
+}
+
+//currentToken returns the token being parsed. past the last token it is an end_of_file token
 func (p *Parser) currentToken() lexer.Token {
-	return p.Tokens[p.TokensConsumed]
+	return p.tokenAt(p.TokensConsumed)
 }
 
 func (p *Parser) nextToken() lexer.Token {
-	return p.Tokens[p.TokensConsumed+1]
+	return p.tokenAt(p.TokensConsumed + 1)
+}
+
+func (p *Parser) tokenAt(index int) lexer.Token {
+	if index < len(p.Tokens) {
+		return p.Tokens[index]
+	}
+	eof := lexer.Token{Type: "end_of_file"}
+	if len(p.Tokens) > 0 {
+		last := p.Tokens[len(p.Tokens)-1]
+		eof.Line = last.Line
+		eof.Col = last.Col + len(last.Value)
+	}
+	return eof
 }
 
 func (p *Parser) advance() {
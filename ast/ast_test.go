@@ -1 +1,147 @@
 package ast
+
+import (
+	"testing"
+
+	"github.com/fabulousduck/smol/lexer"
+)
+
+func parse(program string) ([]Node, *Parser) {
+	l := lexer.NewLexer("test.lo", program)
+	l.Lex()
+	p := NewParser("test.lo", l.Tokens)
+	p.Diagnostics = l.Diagnostics
+	nodes, _ := p.Parse("")
+	return nodes, p
+}
+
+func TestUnclosedBlocks(T *testing.T) {
+	programs := []struct {
+		program, keyword string
+		line, col        int
+	}{
+		{"Uint32 a = 1\nif(a < 2):\n    a++\n", "if", 2, 1},
+		{"Uint32 a = 1\nif(a < 2):\n    a++\nelif(a > 2):\n    a--\n", "elif", 4, 1},
+		{"Uint32 a = 1\nif(a < 2):\n    a++\nelse:\n    a--\n", "else", 4, 1},
+		{"Uint32 a = 1\nwhile(a < 2):\n    a++\n", "while", 2, 1},
+		{"Uint32 a = 1\nwhileNot(a, 2):\n    a++\n", "whileNot", 2, 1},
+		{"Uint32 a = 1\neq(a, 1):\n    a++\n", "eq", 2, 1},
+		{"Uint32 a = 1\nif(a):\n    eq(a, 1):\n        a++\nend\n", "if", 2, 1},
+		{"Uint32 a = 1\nneq(a, 2):\n    a++\n", "neq", 2, 1},
+		{"Uint32 a = 1\ngt(a, 2):\n    a++\n", "gt", 2, 1},
+		{"Uint32 a = 1\nlt(a, 2):\n    a++\n", "lt", 2, 1},
+		{"Uint32 a = 1\ndef f(x):\n    x++\n", "def", 2, 1},
+		{"Uint32 a = 1\nswitch(a):\n    case 1:\n        a++\n    end\n", "switch", 2, 1},
+		{"Uint32 a = 1\nswitch(a):\n    case 1:\n        a++\nend\n", "switch", 2, 1},
+		{"Uint32 a = 1\nasm:\n    CLS\n", "asm", 2, 1},
+	}
+
+	for _, tc := range programs {
+		_, p := parse(tc.program)
+		diagnostics := *p.Diagnostics
+		expected := tc.keyword + " block is not closed with end"
+		if len(diagnostics) != 1 || diagnostics[0].Code != "E0108" || diagnostics[0].Message != expected ||
+			diagnostics[0].Pos.Line != tc.line || diagnostics[0].Pos.Col != tc.col {
+			T.Logf("\nTestUnclosedBlocks | expected %q at %d:%d for %q. got %v", expected, tc.line, tc.col, tc.program, diagnostics)
+			T.Fail()
+		}
+	}
+}
+
+func TestClosedBlocks(T *testing.T) {
+	program := "Uint32 a = 1\nif(a < 2):\n    a++\nelif(a > 2):\n    a--\nelse:\n    while(a):\n        a--\n    end\nend\nlt(a, 2):\n    a++\nend\n" +
+		"def f(x):\n    x++\nend\nswitch(a):\n    case 1:\n        a++\n    end\n    default:\n        a--\n    end\nend\nasm:\n    CLS\nend\n"
+	nodes, p := parse(program)
+	if len(*p.Diagnostics) != 0 || len(nodes) != 6 {
+		T.Logf("\nTestClosedBlocks | expected 6 statements without diagnostics. got %d %v", len(nodes), *p.Diagnostics)
+		T.Fail()
+	}
+}
+
+func TestConditionExpressions(T *testing.T) {
+	nodes, p := parse("Uint32 a = 1\nwhile((a + 1) * 2 < a - 3):\n    a++\nend\n")
+	if len(*p.Diagnostics) != 0 || len(nodes) != 2 {
+		T.Fatalf("\nTestConditionExpressions | expected 2 statements without diagnostics. got %d %v", len(nodes), *p.Diagnostics)
+	}
+
+	condition := nodes[1].(*WhileLoop).Condition
+	left, leftOk := condition.Left.(*Expression)
+	right, rightOk := condition.Right.(*Expression)
+	if !leftOk || !rightOk || condition.Operator != "<" {
+		T.Fatalf("\nTestConditionExpressions | expected an expression on both sides of <. got %+v", condition)
+	}
+
+	rpn := func(e *Expression) string {
+		out := ""
+		for _, token := range e.Tokens {
+			out += token.Value + " "
+		}
+		return out
+	}
+	if rpn(left) != "a 1 + 2 * " || rpn(right) != "a 3 - " {
+		T.Logf("\nTestConditionExpressions | expected a 1 + 2 * and a 3 -. got %q and %q", rpn(left), rpn(right))
+		T.Fail()
+	}
+}
+
+func TestVariableValue(T *testing.T) {
+	nodes, p := parse("Uint32 a = 10\nUint32 b = a\nUint32 c = a + 1\n")
+	if len(*p.Diagnostics) != 0 || len(nodes) != 3 {
+		T.Fatalf("\nTestVariableValue | expected 3 statements without diagnostics. got %d %v", len(nodes), *p.Diagnostics)
+	}
+
+	if value, ok := nodes[0].(*Variable).Value.(*NumLit); !ok || value.Value != "10" {
+		T.Logf("\nTestVariableValue | expected the value of a to be the number 10. got %+v", nodes[0].(*Variable).Value)
+		T.Fail()
+	}
+	if value, ok := nodes[1].(*Variable).Value.(*StatVar); !ok || value.Value != "a" {
+		T.Logf("\nTestVariableValue | expected the value of b to be the variable a. got %+v", nodes[1].(*Variable).Value)
+		T.Fail()
+	}
+	if nodes[2].(*Variable).Value != nil {
+		T.Logf("\nTestVariableValue | expected c to only have a value expression. got %+v", nodes[2].(*Variable).Value)
+		T.Fail()
+	}
+}
+
+func TestComparisonBlockNames(T *testing.T) {
+	nodes, p := parse("Uint32 eq = 1\nUint32 lt = eq\nlt(eq, 2):\n    eq++\nend\nprint(gt)\n")
+	if len(*p.Diagnostics) != 0 || len(nodes) != 4 {
+		T.Fatalf("\nTestComparisonBlockNames | expected 4 statements without diagnostics. got %d %v", len(nodes), *p.Diagnostics)
+	}
+
+	block, ok := nodes[2].(*LtBlock)
+	if !ok || nodes[0].(*Variable).Name != "eq" || nodes[1].(*Variable).Name != "lt" {
+		T.Fatalf("\nTestComparisonBlockNames | expected variables eq and lt and an lt block. got %+v", nodes)
+	}
+	if lhs, ok := block.Lhs.(*StatVar); !ok || lhs.Value != "eq" {
+		T.Logf("\nTestComparisonBlockNames | expected the lt block to compare the variable eq. got %+v", block.Lhs)
+		T.Fail()
+	}
+}
+
+func TestStrayElse(T *testing.T) {
+	programs := []struct {
+		program, keyword string
+		line, statements int
+	}{
+		{"Uint32 a = 1\nelse:\nundefinedfn(a)\nUint32 b = zz\n", "else", 2, 3},
+		{"Uint32 a = 1\nelif(a < 2):\na++\n", "elif", 2, 2},
+		{"def f(x):\n    x++\nelse:\n    x--\nend\nf(1)\n", "else", 3, 2},
+		{"Uint32 a = 1\nwhile(a < 2):\n    a++\nelse:\n    a--\nend\nprint(a)\n", "else", 4, 3},
+	}
+
+	for _, tc := range programs {
+		nodes, p := parse(tc.program)
+		diagnostics := *p.Diagnostics
+		expected := tc.keyword + " without if"
+		if len(diagnostics) != 1 || diagnostics[0].Code != "E0109" || diagnostics[0].Message != expected || diagnostics[0].Pos.Line != tc.line {
+			T.Logf("\nTestStrayElse | expected %q on line %d for %q. got %v", expected, tc.line, tc.program, diagnostics)
+			T.Fail()
+		}
+		if len(nodes) != tc.statements {
+			T.Logf("\nTestStrayElse | expected %d statements after the stray %s in %q. got %d", tc.statements, tc.keyword, tc.program, len(nodes))
+			T.Fail()
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package ast
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fabulousduck/smol/lexer"
+)
+
+/*
+SyntaxNode is a node of the concrete syntax tree.
+Unlike the AST it keeps every token of the program in order,
+so together with the trivia the lexer attaches to them
+it can be printed back exactly as it was written.
+
+Kind is the name of the AST node the statement became, the root is a program.
+Tokens that are not part of a statement, like those of a statement with a syntax error,
+are children of the node around them
+*/
+type SyntaxNode struct {
+	Kind     string
+	Children []SyntaxElement
+
+	start, end int
+	nodes      []*SyntaxNode
+}
+
+//SyntaxElement is a child of a SyntaxNode. either Token or Node is set
+type SyntaxElement struct {
+	Token *lexer.Token
+	Node  *SyntaxNode
+}
+
+/*
+ParseSyntax lexes a program with its trivia and parses it into a concrete syntax tree.
+The parser that built it is returned as well, its Ast and Diagnostics are filled in as usual
+*/
+func ParseSyntax(filename string, source string) (*SyntaxNode, *Parser) {
+	l := lexer.NewLexer(filename, source)
+	l.KeepTrivia = true
+	l.Lex()
+
+	p := NewParser(filename, l.Tokens)
+	p.Diagnostics = l.Diagnostics
+	p.Ast, _ = p.Parse("")
+	return p.Syntax(), p
+}
+
+//Syntax returns the concrete syntax tree of the tokens that have been parsed
+func (p *Parser) Syntax() *SyntaxNode {
+	root := &SyntaxNode{Kind: "program", start: 0, end: len(p.Tokens), nodes: p.syntax.statements}
+	root.build(p.Tokens)
+	return root
+}
+
+//build fills in the children of n from the tokens it spans and the statements inside it
+func (n *SyntaxNode) build(tokens []lexer.Token) {
+	sort.Slice(n.nodes, func(i, j int) bool { return n.nodes[i].start < n.nodes[j].start })
+
+	next := n.start
+	for _, child := range n.nodes {
+		for ; next < child.start; next++ {
+			n.Children = append(n.Children, SyntaxElement{Token: &tokens[next]})
+		}
+		child.build(tokens)
+		n.Children = append(n.Children, SyntaxElement{Node: child})
+		next = child.end
+	}
+	for ; next < n.end && next < len(tokens); next++ {
+		n.Children = append(n.Children, SyntaxElement{Token: &tokens[next]})
+	}
+	n.nodes = nil
+}
+
+//Tokens returns every token under n in the order they were written
+func (n *SyntaxNode) Tokens() []lexer.Token {
+	tokens := []lexer.Token{}
+	for _, child := range n.Children {
+		if child.Node != nil {
+			tokens = append(tokens, child.Node.Tokens()...)
+			continue
+		}
+		tokens = append(tokens, *child.Token)
+	}
+	return tokens
+}
+
+//String prints the source of n with all of its trivia
+func (n *SyntaxNode) String() string {
+	var b strings.Builder
+	for _, token := range n.Tokens() {
+		b.WriteString(token.Leading)
+		b.WriteString(token.Raw)
+		b.WriteString(token.Trailing)
+	}
+	return b.String()
+}
+
+/*
+syntaxRecorder keeps track of the statements the parser is in.
+It is shared with the parsers of block bodies
+so the statements in a block end up under the statement that opened it
+*/
+type syntaxRecorder struct {
+	stack      []*SyntaxNode
+	statements []*SyntaxNode
+}
+
+//open starts a statement at the token with index start
+func (r *syntaxRecorder) open(start int) {
+	r.stack = append(r.stack, &SyntaxNode{start: start})
+}
+
+/*
+close ends the statement that was opened last at the token before end.
+statements that did not become a node, like a syntax error or the end of a block,
+are dropped and the statements inside them move up to the statement around them
+*/
+func (r *syntaxRecorder) close(node Node, end int) {
+	n := r.stack[len(r.stack)-1]
+	r.stack = r.stack[:len(r.stack)-1]
+	n.end = end
+
+	children := []*SyntaxNode{n}
+	if node == nil {
+		children = n.nodes
+	} else {
+		n.Kind = node.GetNodeName()
+	}
+
+	if len(r.stack) == 0 {
+		r.statements = append(r.statements, children...)
+		return
+	}
+	parent := r.stack[len(r.stack)-1]
+	parent.nodes = append(parent.nodes, children...)
+}
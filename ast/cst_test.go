@@ -0,0 +1,70 @@
+package ast
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyntaxRoundTrip(T *testing.T) {
+	sources := []string{
+		"",
+		"  #only a comment",
+		"Uint32 a = 10;   #ten\r\n\r\n\t# indented comment\r\nprint(a) \r\n",
+		"def f(x , y) :\n    print( x )  \n  end\n\n\nf(1,2)",
+		"asm :  \n    LD V0, 1 ; one\n\n  end  \nprint(\"a  b\")\n",
+		"Uint32 a = 1 & 2\nplot(a, )\nif(a < b):\n    print(a)\nend\nend\n",
+		"asm:\n    CLS\n",
+	}
+	files, _ := filepath.Glob("../examples/*.lo")
+	for _, file := range files {
+		source, _ := ioutil.ReadFile(file)
+		sources = append(sources, string(source))
+	}
+
+	for _, source := range sources {
+		tree, _ := ParseSyntax("test.lo", source)
+		if printed := tree.String(); printed != source {
+			T.Logf("\nTestSyntaxRoundTrip | expected %q to print back the same. got %q", source, printed)
+			T.Fail()
+		}
+	}
+}
+
+func TestSyntaxTree(T *testing.T) {
+	tree, p := ParseSyntax("test.lo", "#add\ndef add(a, b):\n    print(a) #first\nend\nadd(1, 2)\n")
+	if len(*p.Diagnostics) != 0 {
+		T.Logf("\nTestSyntaxTree | expected no diagnostics. got %v", *p.Diagnostics)
+		T.FailNow()
+	}
+
+	kinds := []string{}
+	for _, child := range tree.Children {
+		if child.Node != nil {
+			kinds = append(kinds, child.Node.Kind)
+		} else {
+			kinds = append(kinds, child.Token.Type)
+		}
+	}
+	if len(kinds) != 3 || kinds[0] != "function" || kinds[1] != "functionCall" || kinds[2] != "end_of_file" {
+		T.Logf("\nTestSyntaxTree | expected a function, a call and the end of the file. got %v", kinds)
+		T.FailNow()
+	}
+
+	function := tree.Children[0].Node
+	if function.Children[0].Token.Leading != "#add\n" || function.String() != "#add\ndef add(a, b):\n    print(a) #first\nend" {
+		T.Logf("\nTestSyntaxTree | expected the function to keep its comments. got %q", function.String())
+		T.Fail()
+	}
+
+	var body *SyntaxNode
+	for _, child := range function.Children {
+		if child.Node != nil {
+			body = child.Node
+		}
+	}
+	if body == nil || body.Kind != "printCall" || body.Tokens()[len(body.Tokens())-1].Trailing != " #first" {
+		T.Logf("\nTestSyntaxTree | expected the print in the body of add to be a child of it with its comment")
+		T.Fail()
+	}
+}
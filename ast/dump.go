@@ -0,0 +1,96 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+Dump renders an AST as an indented tree.
+Every node is printed with its name and its plain fields on one line.
+Child nodes are printed on the lines below it
+
+	variable Name="a" Type="Uint32"
+	  Value:
+	    numLit Value="10"
+	  ValueExpression:
+	    expression [10]
+*/
+func Dump(nodes []Node) string {
+	var out bytes.Buffer
+	for _, node := range nodes {
+		dumpNode(&out, node, 0)
+	}
+	return out.String()
+}
+
+func dumpNode(out *bytes.Buffer, node Node, indent int) {
+	padding := strings.Repeat(" ", indent)
+	if node == nil || (reflect.ValueOf(node).Kind() == reflect.Ptr && reflect.ValueOf(node).IsNil()) {
+		fmt.Fprintf(out, "%s<nil>\n", padding)
+		return
+	}
+
+	if expression, ok := asExpression(node); ok {
+		fmt.Fprintf(out, "%sexpression %s\n", padding, formatExpression(expression))
+		return
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(node))
+	nodeType := value.Type()
+	fmt.Fprintf(out, "%s%s", padding, node.GetNodeName())
+
+	children := []int{}
+	for i := 0; i < value.NumField(); i++ {
+		field := nodeType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		switch fieldValue := value.Field(i).Interface().(type) {
+		case string:
+			fmt.Fprintf(out, " %s=%q", field.Name, fieldValue)
+		case []string:
+			fmt.Fprintf(out, " %s=%q", field.Name, fieldValue)
+		case int:
+			fmt.Fprintf(out, " %s=%d", field.Name, fieldValue)
+		case Node, []Node, nil:
+			children = append(children, i)
+		}
+	}
+	out.WriteString("\n")
+
+	for _, i := range children {
+		fmt.Fprintf(out, "%s  %s:\n", padding, nodeType.Field(i).Name)
+		switch child := value.Field(i).Interface().(type) {
+		case []Node:
+			for _, n := range child {
+				dumpNode(out, n, indent+4)
+			}
+		case Node:
+			dumpNode(out, child, indent+4)
+		default:
+			fmt.Fprintf(out, "%s    <nil>\n", padding)
+		}
+	}
+}
+
+func asExpression(node Node) (Expression, bool) {
+	switch expression := node.(type) {
+	case Expression:
+		return expression, true
+	case *Expression:
+		return *expression, true
+	}
+	return Expression{}, false
+}
+
+//formatExpression prints the RPN tokens of an expression as [a b <]
+func formatExpression(expression Expression) string {
+	values := []string{}
+	for _, token := range expression.Tokens {
+		values = append(values, token.Value)
+	}
+	return "[" + strings.Join(values, " ") + "]"
+}
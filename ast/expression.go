@@ -1,14 +1,13 @@
 package ast
 
 import (
-	"os"
-
 	"github.com/fabulousduck/smol/errors"
 	"github.com/fabulousduck/smol/lexer"
 )
 
 //Expression contains nodes in RPN form
 type Expression struct {
+	Position
 	Tokens []lexer.Token
 }
 
@@ -25,6 +24,7 @@ func (e Expression) GetNodeName() string {
 
 //VariableReference contains the name of a referenced variable during AST generation
 type VariableReference struct {
+	Position
 	name string
 }
 
@@ -40,6 +40,7 @@ func createVariableReference(name string) VariableReference {
 
 //Operator is a symbol that operates on one or more sides
 type Operator struct {
+	Position
 	value string
 }
 
@@ -50,6 +51,7 @@ func (o Operator) GetNodeName() string {
 
 //Symbol is a character that is not and operator or a latin character / numeral
 type Symbol struct {
+	Position
 	value string
 }
 
@@ -60,6 +62,7 @@ func (s Symbol) GetNodeName() string {
 
 //Litteral is a node type for static values such as integers and string litterals
 type Litteral struct {
+	Position
 	ltype string
 	value string
 }
@@ -88,34 +91,27 @@ func (p *Parser) readExpression() Expression {
 	expressionTokens := []lexer.Token{}
 
 	//gather all tokens of the expression into a slice
-	for currTok := p.currentToken(); currTok.Line == expressionLine; currTok = p.currentToken() {
-		expressionTokens = append(expressionTokens, currTok)
+	for p.TokensConsumed < len(p.Tokens) && p.currentToken().Line == expressionLine {
+		expressionTokens = append(expressionTokens, p.currentToken())
 		p.advance()
-		if !p.nextExists() {
-			expressionTokens = append(expressionTokens, p.currentToken())
-			break
-		}
 	}
-	expressionParser := NewParser(p.Filename, expressionTokens)
+	expressionParser := p.subParser(expressionTokens)
 
+	expression := createExpression([]lexer.Token{})
 	switch len(expressionTokens) {
 	case 0:
-		errors.ExpectedExpressionError()
-		os.Exit(65)
-		break
+		p.fail(errors.ExpectedExpressionError(), p.currentToken())
 	case 1:
-		if lexer.IsLitteral(expressionTokens[0]) {
-			litteralToken := expressionTokens[0]
-			return createExpression([]lexer.Token{litteralToken})
+		if !lexer.IsLitteral(expressionTokens[0]) {
+			p.fail(errors.InvalidOperatorError(), expressionTokens[0])
 		}
-		errors.InvalidOperatorError()
-		os.Exit(65)
-		break
+		expression = createExpression([]lexer.Token{expressionTokens[0]})
 	default:
-		return expressionParser.parseExpression()
+		expression = expressionParser.parseExpression()
 	}
 
-	return createExpression([]lexer.Token{})
+	expression.Span = p.spanOf(expressionTokens)
+	return expression
 }
 
 /*
@@ -129,7 +125,7 @@ func (p *Parser) readExpressionUntil(tokValues []string) (Expression, string) {
 	fnContext := false
 	delimFound := ""
 
-	for i := 0; i < len(p.Tokens); i++ {
+	for i := 0; i < len(p.Tokens) && p.hasCurrent(); i++ {
 		if containsStr(tokValues, p.currentToken().Value) && !fnContext {
 			delimFound = p.currentToken().Value
 			break
@@ -146,26 +142,24 @@ func (p *Parser) readExpressionUntil(tokValues []string) (Expression, string) {
 
 	}
 
-	expressionParser := NewParser(p.Filename, expressionTokens)
+	expressionParser := p.subParser(expressionTokens)
 	expressionParser.Tokens = expressionTokens
 
+	expression := createExpression([]lexer.Token{})
 	switch len(expressionTokens) {
 	case 0:
-		errors.ExpectedExpressionError()
-		os.Exit(65)
-		break
+		p.fail(errors.ExpectedExpressionError(), p.currentToken())
 	case 1:
-		if lexer.IsLitteral(expressionTokens[0]) {
-			return createExpression([]lexer.Token{expressionTokens[0]}), delimFound
+		if !lexer.IsLitteral(expressionTokens[0]) {
+			p.fail(errors.InvalidOperatorError(), expressionTokens[0])
 		}
-		errors.InvalidOperatorError()
-		os.Exit(65)
-		break
+		expression = createExpression([]lexer.Token{expressionTokens[0]})
 	default:
-		return expressionParser.parseExpression(), delimFound
+		expression = expressionParser.parseExpression()
 	}
 
-	return createExpression([]lexer.Token{}), delimFound
+	expression.Span = p.spanOf(expressionTokens)
+	return expression, delimFound
 }
 
 func (p *Parser) parseExpression() Expression {
@@ -174,9 +168,15 @@ func (p *Parser) parseExpression() Expression {
 	for p.TokensConsumed < len(p.Tokens) {
 		token := p.currentToken()
 		switch token.Type {
+		case "semicolon":
+			fallthrough
 		case "comma":
 			p.advance()
 			break
+		case "string_litteral":
+			fallthrough
+		case "boolean_keyword":
+			fallthrough
 		case "integer":
 			outputQueue = append(outputQueue, token)
 			p.advance()
@@ -241,7 +241,8 @@ func (p *Parser) parseExpression() Expression {
 
 			p.advance()
 			break
-
+		default:
+			p.advance()
 		}
 	}
 	if len(operatorStack) != 0 {
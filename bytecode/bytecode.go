@@ -1,19 +1,22 @@
 package bytecode
 
 import (
-	"os"
-
-	"github.com/fabulousduck/smol/file"
+	"github.com/fabulousduck/smol/errors"
 	"github.com/fabulousduck/smol/ir"
+	"github.com/fabulousduck/smol/opcode"
 )
 
 /*
-Generator holds the file pointer to the binary ROM
+Generator holds the IR the ROM is generated from
 and other info relevant to generating the opcodes
 */
 type Generator struct {
 	filename string
 	ir       *ir.Generator
+
+	//addresses holds the address of every instruction of the IR after Build, and the end of the code last
+	addresses []int
+	img       *Image
 }
 
 /*
@@ -28,57 +31,88 @@ func Init(ir *ir.Generator, filename string) *Generator {
 }
 
 /*
-CreateRom generates a rom from an existing IR
+Build lays the IR out in an in-memory image,
+resolves all labels and returns the bytes of the ROM
 */
-func (g *Generator) CreateRom() {
-	romFile := file.Create(g.filename)
+func (g *Generator) Build() ([]byte, error) {
+	img := NewImage()
+	g.img = img
+	g.addresses = make([]int, 0, len(g.ir.Ir)+1)
 
 	for i := 0; i < len(g.ir.Ir); i++ {
+		g.addresses = append(g.addresses, img.Address())
 		instructionType := g.ir.Ir[i].GetInstructionName()
 		switch instructionType {
+		case "LABEL":
+			labelInstruction := g.ir.Ir[i].(ir.Label)
+			if err := img.Label(labelInstruction.Name); err != nil {
+				return nil, err
+			}
 		case "SETREG":
 			setRegInstruction := g.ir.Ir[i].(ir.SETREG)
-			g.embedSetRegister(setRegInstruction, romFile)
+			g.embedSetRegister(setRegInstruction, img)
 		case "SETMEM":
 			setMemInstruction := g.ir.Ir[i].(ir.SETMEM)
-			//write a single byte into memory
-			file.WriteBytes(romFile, []byte{byte(uint8(setMemInstruction.Val))}, true, int64(setMemInstruction.Addr))
-			break
+			g.embedSetMemory(setMemInstruction, img)
 		case "RegCpy":
 			regCpyInstruction := g.ir.Ir[i].(ir.RegCpy)
-			g.embedRegCpy(regCpyInstruction, romFile)
+			g.embedRegCpy(regCpyInstruction, img)
 		case "MOV":
 			movInstruction := g.ir.Ir[i].(ir.MOV)
 			if movInstruction.ANNN {
-				g.embedANNN(movInstruction, romFile)
+				g.embedANNN(movInstruction, img)
 				break
 			}
-			g.embedMOV(movInstruction, romFile)
+			g.embedMOV(movInstruction, img)
+		case "ADDRR":
+			addrrInstruction := g.ir.Ir[i].(ir.ADDRR)
+			g.embedAddRR(addrrInstruction, img)
 		case "SUB":
 			subInstruction := g.ir.Ir[i].(ir.SUB)
-			g.embedSub(subInstruction, romFile)
+			g.embedSub(subInstruction, img)
+		case "SUBN":
+			subnInstruction := g.ir.Ir[i].(ir.SUBN)
+			g.embedSubN(subnInstruction, img)
 		case "RET":
-			g.embedRet(romFile)
-
+			g.embedRet(img)
+		case "FNJMP":
+			fnjmpInstruction := g.ir.Ir[i].(ir.FNJMP)
+			g.embedFNJMP(fnjmpInstruction, img)
+		case "RGD":
+			rgdInstruction := g.ir.Ir[i].(ir.RGD)
+			g.embedRGD(rgdInstruction, img)
 		case "ADD":
 			addInstruction := g.ir.Ir[i].(ir.ADD)
-			g.embedAdd(addInstruction, romFile)
+			g.embedAdd(addInstruction, img)
 		case "BNE":
 			bneInstruction := g.ir.Ir[i].(ir.BNE)
-			g.embedBNE(bneInstruction, romFile)
+			g.embedBNE(bneInstruction, img)
+		case "BEQ":
+			beqInstruction := g.ir.Ir[i].(ir.BEQ)
+			g.embedBEQ(beqInstruction, img)
 		case "BNERR":
 			bnerrInstruction := g.ir.Ir[i].(ir.BNERR)
-			g.embedBNERR(bnerrInstruction, romFile)
+			g.embedBNERR(bnerrInstruction, img)
+		case "BEQRR":
+			beqrrInstruction := g.ir.Ir[i].(ir.BEQRR)
+			g.embedBEQRR(beqrrInstruction, img)
 		case "PLOT":
 			plotInstruction := g.ir.Ir[i].(ir.PLOT)
-			g.embedPLOT(plotInstruction, romFile)
+			g.embedPLOT(plotInstruction, img)
 		case "Jump":
 			jmpInstruction := g.ir.Ir[i].(ir.Jump)
-			g.embedJMP(jmpInstruction, romFile)
-
+			g.embedJMP(jmpInstruction, img)
+		case "RAW":
+			rawInstruction := g.ir.Ir[i].(ir.RAW)
+			g.embedRAW(rawInstruction, img)
+		default:
+			//anything we do not know how to encode would silently shift every address after it
+			return nil, errors.UnknownInstructionError(instructionType).InFile(g.filename)
 		}
 	}
-	return
+	g.addresses = append(g.addresses, img.Address())
+
+	return img.Resolve()
 }
 
 /*
@@ -87,8 +121,41 @@ opcode: 00EE
 
 literally just a return opcode. no data is encoded in this thing
 */
-func (g *Generator) embedRet(romFile *os.File) {
+func (g *Generator) embedRet(img *Image) {
+	img.Emit(opcode.Return())
+}
+
+/*
+2NNN
+opcode: 2NNN
+NNN: address of the function to call
+*/
+func (g *Generator) embedFNJMP(instruction ir.FNJMP, img *Image) {
+	if instruction.Label != "" {
+		img.EmitFixup(opcode.Call(0), instruction.Label, 0)
+		return
+	}
+	img.Emit(opcode.Call(instruction.Addr))
+}
+
+/*
+FX55
+opcode: FX55
+X: last register to store. V0 through VX are stored at I
+
+the IR points I at the start location before the RGD
+*/
+func (g *Generator) embedRGD(instruction ir.RGD, img *Image) {
+	img.Emit(opcode.StoreRegisters(instruction.Count))
+}
 
+/*
+SETMEM is not an opcode. the byte is placed straight into
+variable space at its address in the ROM, the same way
+MOV I addresses it
+*/
+func (g *Generator) embedSetMemory(instruction ir.SETMEM, img *Image) {
+	img.Place(ProgramStart+instruction.Addr, []byte{byte(uint8(instruction.Val))})
 }
 
 /*
@@ -97,12 +164,18 @@ opcode: 8XY5
 
 Opcode for subtracting one register from another
 */
-func (g *Generator) embedSub(instruction ir.SUB, romFile *os.File) {
-	baseByte := 0x8<<4 | instruction.TargetRegister
-	secondaryByte := instruction.AmountRegister<<4 | 0x5
+func (g *Generator) embedSub(instruction ir.SUB, img *Image) {
+	img.Emit(opcode.Sub(instruction.TargetRegister, instruction.AmountRegister))
+}
 
-	opcode := []byte{clampUint8(baseByte), clampUint8(secondaryByte)}
-	file.WriteBytes(romFile, opcode, false, 0)
+/*
+8XY7
+opcode: 8XY7
+
+Opcode for subtracting a register from another one and storing the result in the first
+*/
+func (g *Generator) embedSubN(instruction ir.SUBN, img *Image) {
+	img.Emit(opcode.SubN(instruction.TargetRegister, instruction.AmountRegister))
 }
 
 /*
@@ -111,28 +184,54 @@ V[x] += V[y]
 	X: register to add value onto
 	NN: value to add onto registerX
 */
-func (g *Generator) embedAdd(instruction ir.ADD, romFile *os.File) {
-	baseByte := 0x7<<4 | instruction.Register
-	secondaryByte := instruction.Value
+func (g *Generator) embedAdd(instruction ir.ADD, img *Image) {
+	img.Emit(opcode.AddByte(instruction.Register, instruction.Value))
+}
 
-	opcode := []byte{clampUint8(baseByte), clampUint8(secondaryByte)}
-	file.WriteBytes(romFile, opcode, false, 0)
+/*
+V[x] += V[y]
+	opcode: 8XY4
+	X: register to add onto
+	Y: register holding the value to add onto registerX
+*/
+func (g *Generator) embedAddRR(instruction ir.ADDRR, img *Image) {
+	img.Emit(opcode.AddRegister(instruction.Register, instruction.AmountRegister))
 }
 
-func (g *Generator) embedBNE(instruction ir.BNE, romFile *os.File) {
-	baseByte := 0x3
-	baseByte = baseByte<<4 | instruction.Lhs
-	secondaryByte := instruction.Rhs
-	opcode := []byte{clampUint8(baseByte), clampUint8(secondaryByte)}
-	file.WriteBytes(romFile, opcode, false, 0)
+/*
+	opcode: 3XNN
+	X: lhs register
+	NN: rhs value
+*/
+func (g *Generator) embedBNE(instruction ir.BNE, img *Image) {
+	img.Emit(opcode.SkipEqual(instruction.Lhs, instruction.Rhs))
+}
+
+/*
+	opcode: 4XNN
+	X: lhs register
+	NN: rhs value
+*/
+func (g *Generator) embedBEQ(instruction ir.BEQ, img *Image) {
+	img.Emit(opcode.SkipNotEqual(instruction.Lhs, instruction.Rhs))
 }
 
-func (g *Generator) embedBNERR(instruction ir.BNERR, romFile *os.File) {
-	baseByte := 0x5
-	baseByte = baseByte<<4 | instruction.Lhs
-	secondaryByte := instruction.Rhs<<4 | 0
-	opcode := []byte{clampUint8(baseByte), clampUint8(secondaryByte)}
-	file.WriteBytes(romFile, opcode, false, 0)
+/*
+	opcode: 5XY0
+	X: lhs register
+	Y: rhs register
+*/
+func (g *Generator) embedBNERR(instruction ir.BNERR, img *Image) {
+	img.Emit(opcode.SkipRegistersEqual(instruction.Lhs, instruction.Rhs))
+}
+
+/*
+	opcode: 9XY0
+	X: lhs register
+	Y: rhs register
+*/
+func (g *Generator) embedBEQRR(instruction ir.BEQRR, img *Image) {
+	img.Emit(opcode.SkipRegistersNotEqual(instruction.Lhs, instruction.Rhs))
 }
 
 /*
@@ -140,13 +239,8 @@ func (g *Generator) embedBNERR(instruction ir.BNERR, romFile *os.File) {
 	X: register where the original value is stored
 	Y: register where the value is to be copied to
 */
-func (g *Generator) embedRegCpy(instruction ir.RegCpy, romFile *os.File) {
-	baseByte := 0x8
-	baseByte = baseByte<<4 | instruction.To
-	secondaryByte := instruction.From<<4 | 0
-
-	opcode := []byte{clampUint8(baseByte), clampUint8(secondaryByte)}
-	file.WriteBytes(romFile, opcode, false, 0)
+func (g *Generator) embedRegCpy(instruction ir.RegCpy, img *Image) {
+	img.Emit(opcode.LoadRegister(instruction.To, instruction.From))
 }
 
 /*
@@ -154,12 +248,8 @@ func (g *Generator) embedRegCpy(instruction ir.RegCpy, romFile *os.File) {
 	X: index of the register that the value will be placed in
 	NN: the value to be placed in the register
 */
-func (g *Generator) embedSetRegister(instruction ir.SETREG, romFile *os.File) {
-	baseByte := 0x6
-	baseByte = baseByte<<4 | instruction.Index
-	secondaryByte := instruction.Val
-	opcode := []byte{clampUint8(baseByte), clampUint8(secondaryByte)}
-	file.WriteBytes(romFile, opcode, false, 0)
+func (g *Generator) embedSetRegister(instruction ir.SETREG, img *Image) {
+	img.Emit(opcode.Load(instruction.Index, instruction.Val))
 }
 
 /*
@@ -167,12 +257,12 @@ func (g *Generator) embedSetRegister(instruction ir.SETREG, romFile *os.File) {
 	1: identifier
 	NNN: address to jump to
 */
-func (g *Generator) embedJMP(instruction ir.Jump, romFile *os.File) {
-	baseByte := 0x1
-	baseByte = baseByte<<4 | shiftRight(instruction.To)
-
-	secondaryByte := instruction.To & 0x0FF
-	file.WriteBytes(romFile, []byte{clampUint8(baseByte), clampUint8(secondaryByte)}, false, 0)
+func (g *Generator) embedJMP(instruction ir.Jump, img *Image) {
+	if instruction.Label != "" {
+		img.EmitFixup(opcode.Jump(0), instruction.Label, 0)
+		return
+	}
+	img.Emit(opcode.Jump(instruction.To))
 }
 
 /*
@@ -180,15 +270,9 @@ func (g *Generator) embedJMP(instruction ir.Jump, romFile *os.File) {
 	A: identifier
 	NNNN: address to move into I
 */
-func (g *Generator) embedANNN(instruction ir.MOV, romFile *os.File) {
-	instruction.R2 += 0x200 //generate and address that is relative to the machine, not the file
-	baseByte := 0xA
-	secondaryByte := 0x00
-	baseByte = baseByte<<4 | shiftRight(instruction.R2)
-
-	secondaryByte = instruction.R2 & 0x0FF
-
-	file.WriteBytes(romFile, []byte{clampUint8(baseByte), clampUint8(secondaryByte)}, false, 0)
+func (g *Generator) embedANNN(instruction ir.MOV, img *Image) {
+	//variable space addresses are relative to the start of the ROM, not the machine
+	img.EmitFixup(opcode.LoadI(0), StartLabel, instruction.R2)
 }
 
 /*
@@ -199,14 +283,8 @@ Y: register index containing the Y coordinate
 N: number of columns to draw
 */
 
-func (g *Generator) embedPLOT(instruction ir.PLOT, romFile *os.File) {
-
-	baseByte := 0xD
-	baseByte = baseByte<<4 | instruction.X
-
-	secondaryByte := instruction.Y<<4 | instruction.H
-
-	file.WriteBytes(romFile, []byte{clampUint8(baseByte), clampUint8(secondaryByte)}, false, 0)
+func (g *Generator) embedPLOT(instruction ir.PLOT, img *Image) {
+	img.Emit(opcode.Draw(instruction.X, instruction.Y, instruction.H))
 }
 
 /*
@@ -215,31 +293,18 @@ func (g *Generator) embedPLOT(instruction ir.PLOT, romFile *os.File) {
 	X: register index
 	NN: value to be moved into register
 */
-func (g *Generator) embedMOV(instruction ir.MOV, romFile *os.File) {
-
-	baseByte := 0x6
-
-	baseByte = baseByte<<4 | instruction.R1
-	file.WriteBytes(romFile, []byte{clampUint8(baseByte), clampUint8(instruction.R2)}, false, 0)
+func (g *Generator) embedMOV(instruction ir.MOV, img *Image) {
+	img.Emit(opcode.Load(instruction.R1, instruction.R2))
 }
 
 /*
-	shifts the left most value in an int to the right
-	this is used when we need to split up a number to append it
-	to the right in a byte
+	opcode: any
+	the opcode of an inline asm instruction. it is already encoded
 */
-func shiftRight(value int) int {
-	if value <= 16 {
-		return value
-	} else if value < 256 {
-		return (value & 0xF0 >> 4)
-	} else if value < 4096 {
-		return (value & 0xF00 >> 8)
-	} else {
-		return (value & 0xF000 >> 16)
+func (g *Generator) embedRAW(instruction ir.RAW, img *Image) {
+	if instruction.Label != "" {
+		img.EmitFixup(opcode.Opcode(instruction.Opcode), instruction.Label, instruction.Opcode&0xFFF)
+		return
 	}
-}
-
-func clampUint8(variable int) byte {
-	return byte(uint8(variable))
+	img.Emit(opcode.Opcode(instruction.Opcode))
 }
@@ -0,0 +1,103 @@
+package bytecode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fabulousduck/smol/ir"
+)
+
+//createRom turns IR in its text form into a ROM
+func createRom(T *testing.T, source string) []byte {
+	g, err := ir.ParseText("TESTING", source)
+	if err != nil {
+		T.Fatal(err)
+	}
+
+	rom, err := Init(g, "TESTING").Build()
+	if err != nil {
+		T.Fatal(err)
+	}
+	return rom
+}
+
+func TestOpcodes(T *testing.T) {
+	instructions := []struct {
+		ir       string
+		offset   int
+		expected []byte
+	}{
+		{"SETREG V3 10", 0, []byte{0x63, 0x0A}},
+		{"SETMEM 0xCA0 128", 0xCA0, []byte{0x80}},
+		{"RegCpy V0 VE", 0, []byte{0x8E, 0x00}},
+		{"ADD V0 1", 0, []byte{0x70, 0x01}},
+		{"ADDRR V0 V3", 0, []byte{0x80, 0x34}},
+		{"SUB V1 V2", 0, []byte{0x81, 0x25}},
+		{"SUBN V1 V2", 0, []byte{0x81, 0x27}},
+		{"BNE VC 255", 0, []byte{0x3C, 0xFF}},
+		{"BEQ VC 255", 0, []byte{0x4C, 0xFF}},
+		{"BNERR V1 V2", 0, []byte{0x51, 0x20}},
+		{"BEQRR V1 V2", 0, []byte{0x91, 0x20}},
+		{"PLOT VE VD 1", 0, []byte{0xDE, 0xD1}},
+		{"MOV I 0xCA0", 0, []byte{0xAE, 0xA0}},
+		{"MOV V1 10", 0, []byte{0x61, 0x0A}},
+		{"Jump 0x206", 0, []byte{0x12, 0x06}},
+		{"FNJMP 0x202", 0, []byte{0x22, 0x02}},
+		{"RET", 0, []byte{0x00, 0xEE}},
+		{"RGD 0xCA0 3", 0, []byte{0xF3, 0x55}},
+		{"RAW 0xF033", 0, []byte{0xF0, 0x33}},
+	}
+
+	for _, instruction := range instructions {
+		rom := createRom(T, instruction.ir)
+		end := instruction.offset + len(instruction.expected)
+		if len(rom) < end || !bytes.Equal(rom[instruction.offset:end], instruction.expected) {
+			T.Logf("\nTestOpcodes | %s generated % X. expected % X at 0x%X", instruction.ir, rom, instruction.expected, instruction.offset)
+			T.Fail()
+		}
+	}
+}
+
+//a called function has to come back to the instruction after its call
+func TestFunctionCall(T *testing.T) {
+	rom := createRom(T, "Jump main\nfn: ADD V0 1\nRET\nmain: FNJMP fn\nFNJMP fn\n")
+	expected := []byte{0x12, 0x06, 0x70, 0x01, 0x00, 0xEE, 0x22, 0x02, 0x22, 0x02}
+	if !bytes.Equal(rom, expected) {
+		T.Logf("\nTestFunctionCall | got % X. expected % X", rom, expected)
+		T.Fail()
+	}
+}
+
+//SETMEM and labels take up no room in the code so they must not shift the addresses after them
+func TestLayout(T *testing.T) {
+	rom := createRom(T, "SETMEM 0xCA0 128\nJump skip\nMOV I 0xCA0\nskip: RAW 0x1002 skip\n")
+	expected := []byte{0x12, 0x04, 0xAE, 0xA0, 0x12, 0x06}
+	if len(rom) != 0xCA1 || !bytes.Equal(rom[:len(expected)], expected) || rom[0xCA0] != 0x80 {
+		T.Logf("\nTestLayout | got % X", rom[:len(expected)])
+		T.Fail()
+	}
+}
+
+func TestImageErrors(T *testing.T) {
+	img := NewImage()
+	img.EmitFixup(0x1000, "nowhere", 0)
+	if _, err := img.Resolve(); err == nil {
+		T.Logf("\nTestImageErrors | undefined label was resolved")
+		T.Fail()
+	}
+
+	img = NewImage()
+	img.Label("a")
+	if img.Label("a") == nil {
+		T.Logf("\nTestImageErrors | label defined twice without an error")
+		T.Fail()
+	}
+
+	img = NewImage()
+	img.Emit(0x00E0)
+	img.Place(ProgramStart, []byte{0x80})
+	if _, err := img.Resolve(); err == nil {
+		T.Logf("\nTestImageErrors | data overlapping code was accepted")
+		T.Fail()
+	}
+}
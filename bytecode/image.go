@@ -0,0 +1,125 @@
+package bytecode
+
+import (
+	"fmt"
+
+	"github.com/fabulousduck/smol/opcode"
+)
+
+//ProgramStart is the address chip-8 loads the ROM at
+const ProgramStart = 0x200
+
+//StartLabel is defined in every image and points at ProgramStart.
+//addresses relative to the start of the ROM, like those of variable space, use it
+const StartLabel = "rom.start"
+
+/*
+fixup is an opcode in the image whose NNN still has to be filled in.
+its address is that of label plus addend
+*/
+type fixup struct {
+	offset, addend int
+	label          string
+}
+
+/*
+Image is a ROM that is built in memory.
+
+Code is appended one opcode at a time. Opcodes that point at a label
+that might not be known yet are patched by Resolve once all code has been laid out,
+so no address has to be guessed while generating code.
+Data like variable space is placed at a fixed address
+*/
+type Image struct {
+	code   []byte
+	data   map[int]byte
+	labels map[string]int
+	fixups []fixup
+}
+
+//NewImage creates an empty image
+func NewImage() *Image {
+	img := new(Image)
+	img.data = make(map[int]byte)
+	img.labels = map[string]int{StartLabel: ProgramStart}
+	return img
+}
+
+//Address returns the address the next opcode will be placed at
+func (img *Image) Address() int {
+	return ProgramStart + len(img.code)
+}
+
+//Label marks the current address with name
+func (img *Image) Label(name string) error {
+	if _, ok := img.labels[name]; ok {
+		return fmt.Errorf("label %s is defined twice", name)
+	}
+	img.labels[name] = img.Address()
+	return nil
+}
+
+//LabelAddress returns the address label marks
+func (img *Image) LabelAddress(name string) (int, bool) {
+	addr, ok := img.labels[name]
+	return addr, ok
+}
+
+//Emit appends an opcode to the code
+func (img *Image) Emit(op opcode.Opcode) {
+	img.code = append(img.code, op.Bytes()...)
+}
+
+/*
+EmitFixup appends an opcode whose NNN is filled in by Resolve.
+the NNN becomes the address of label plus addend
+*/
+func (img *Image) EmitFixup(op opcode.Opcode, label string, addend int) {
+	img.fixups = append(img.fixups, fixup{offset: len(img.code), addend: addend, label: label})
+	img.Emit(op &^ 0x0FFF)
+}
+
+//Place puts data at a fixed address
+func (img *Image) Place(addr int, data []byte) {
+	for i, b := range data {
+		img.data[addr+i] = b
+	}
+}
+
+/*
+Resolve patches every fixup with the address of its label
+and returns the bytes of the ROM starting at ProgramStart
+*/
+func (img *Image) Resolve() ([]byte, error) {
+	code := make([]byte, len(img.code))
+	copy(code, img.code)
+
+	for _, f := range img.fixups {
+		addr, ok := img.labels[f.label]
+		if !ok {
+			return nil, fmt.Errorf("undefined label %s", f.label)
+		}
+		addr += f.addend
+		if addr < 0 || addr > 0xFFF {
+			return nil, fmt.Errorf("address 0x%X of %s is outside of memory", addr, f.label)
+		}
+		code[f.offset] |= byte(addr >> 8)
+		code[f.offset+1] = byte(addr)
+	}
+
+	rom := code
+	for addr, b := range img.data {
+		offset := addr - ProgramStart
+		if offset < 0 || addr > 0xFFF {
+			return nil, fmt.Errorf("data at 0x%X is outside of program space", addr)
+		}
+		if offset < len(img.code) {
+			return nil, fmt.Errorf("data at 0x%X overlaps the code, which ends at 0x%X", addr, img.Address())
+		}
+		for len(rom) <= offset {
+			rom = append(rom, 0)
+		}
+		rom[offset] = b
+	}
+	return rom, nil
+}
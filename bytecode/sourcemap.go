@@ -0,0 +1,67 @@
+package bytecode
+
+import (
+	"sort"
+
+	"github.com/fabulousduck/smol/sourcemap"
+)
+
+/*
+SourceMap maps the ROM made by Build back to the source of the program.
+The lines come from the debug line table of the IR, the variables from the
+register and memory allocation and the functions from the labels around their code.
+It returns nil when Build has not been run
+*/
+func (g *Generator) SourceMap() *sourcemap.Map {
+	if g.img == nil {
+		return nil
+	}
+	m := &sourcemap.Map{File: g.filename, Lines: []sourcemap.Line{}, Variables: []sourcemap.Variable{}, Functions: []sourcemap.Function{}}
+
+	for i, line := range g.ir.Lines {
+		end := len(g.ir.Ir)
+		if i+1 < len(g.ir.Lines) {
+			end = g.ir.Lines[i+1].Instruction
+		}
+		//labels and SETMEM do not emit code, so some lines have no opcodes of their own
+		if g.addresses[line.Instruction] == g.addresses[end] {
+			continue
+		}
+		m.Lines = append(m.Lines, sourcemap.Line{
+			Addr:    g.addresses[line.Instruction],
+			End:     g.addresses[end],
+			Line:    line.Span.Start.Line,
+			Col:     line.Span.Start.Col,
+			EndLine: line.Span.End.Line,
+			EndCol:  line.Span.End.Col,
+		})
+	}
+
+	variables := map[string]*sourcemap.Variable{}
+	variable := func(name string) *sourcemap.Variable {
+		if variables[name] == nil {
+			variables[name] = &sourcemap.Variable{Name: name, Register: -1, Addr: -1}
+		}
+		return variables[name]
+	}
+	for name, register := range g.ir.Variables() {
+		variable(name).Register = register
+	}
+	for name, region := range g.ir.MemTable() {
+		variable(name).Addr = ProgramStart + region.Addr
+	}
+	for _, v := range variables {
+		m.Variables = append(m.Variables, *v)
+	}
+	sort.Slice(m.Variables, func(i, j int) bool { return m.Variables[i].Name < m.Variables[j].Name })
+
+	for _, function := range g.ir.Functions() {
+		start, ok := g.img.LabelAddress(function.Label)
+		end, hasEnd := g.img.LabelAddress(function.Label + ".end")
+		if !ok || !hasEnd {
+			continue
+		}
+		m.Functions = append(m.Functions, sourcemap.Function{Name: function.Name, Start: start, End: end})
+	}
+	return m
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/fabulousduck/smol/asm"
+	"github.com/fabulousduck/smol/diag"
+)
+
+/*
+asmCommand assembles a chip-8 assembly file into a ROM
+
+smol asm [-o ROM] file.s
+*/
+func asmCommand(args []string) {
+	flags := flag.NewFlagSet("asm", flag.ExitOnError)
+	output := flags.String("o", "ROM", "file to write the ROM to")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: smol asm [-o ROM] file")
+		os.Exit(2)
+	}
+
+	filename := flags.Arg(0)
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	rom, err := asm.Assemble(filename, string(source))
+	if err != nil {
+		renderer := diag.NewRenderer(os.Stderr)
+		renderer.Sources[filename] = string(source)
+		renderer.RenderOne(err.(diag.Diagnostic))
+		os.Exit(65)
+	}
+
+	if err := ioutil.WriteFile(*output, rom, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/fabulousduck/smol"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/sourcemap"
+)
+
+/*
+buildCommand compiles a .lo or .ir file into a ROM
+
+smol build [-o | --output ROM] [--diagnostics=text|json] [--map=json|binary] file.lo
+
+with --map the source map of the ROM is written next to it, to ROM.map
+*/
+func buildCommand(args []string) {
+	os.Exit(build(args, os.Stdout, os.Stderr))
+}
+
+/*
+build does the work of buildCommand and returns the exit code.
+text diagnostics go to stderr, json diagnostics go to stdout
+one object per line so tools can read them as they come
+*/
+func build(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := "ROM"
+	flags.StringVar(&output, "o", output, "file to write the ROM to")
+	flags.StringVar(&output, "output", output, "file to write the ROM to")
+	format := flags.String("diagnostics", "text", "how diagnostics are printed. one of text, json")
+	mapFormat := flags.String("map", "", "write a source map to the ROM's name plus .map. one of json, binary")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 || (*format != "text" && *format != "json") ||
+		(*mapFormat != "" && *mapFormat != "json" && *mapFormat != "binary") {
+		fmt.Fprintln(stderr, "usage: smol build [-o | --output ROM] [--diagnostics=text|json] [--map=json|binary] file")
+		return 2
+	}
+
+	filename := flags.Arg(0)
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	rom, sourceMap, diagnostics := smol.CompileWithMap(string(source), filename, smol.Options{})
+	if *format == "json" {
+		diag.WriteJSON(stdout, diagnostics)
+	} else {
+		renderer := diag.NewRenderer(stderr)
+		renderer.Sources[filename] = string(source)
+		renderer.Render(diagnostics)
+	}
+	if rom == nil {
+		return 65
+	}
+
+	if err := ioutil.WriteFile(output, rom, 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *mapFormat != "" {
+		if err := writeMap(output+".map", sourceMap, *mapFormat); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+//writeMap writes m to path in the given format
+func writeMap(path string, m *sourcemap.Map, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if format == "binary" {
+		err = m.WriteBinary(f)
+	} else {
+		err = m.WriteJSON(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
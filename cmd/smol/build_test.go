@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildJSONDiagnostics(T *testing.T) {
+	dir := T.TempDir()
+	source := filepath.Join(dir, "bad.lo")
+	ioutil.WriteFile(source, []byte("Uint32 a = 1\nplot(a, b)\nplot(a, )\n"), 0644)
+
+	var stdout, stderr bytes.Buffer
+	code := build([]string{"--diagnostics=json", "-o", filepath.Join(dir, "ROM"), source}, &stdout, &stderr)
+	if code != 65 || stderr.Len() != 0 {
+		T.Logf("\nTestBuildJSONDiagnostics | expected exit code 65 and nothing on stderr. got %d %q", code, stderr.String())
+		T.Fail()
+	}
+
+	type region struct {
+		StartLine, StartColumn, EndLine, EndColumn int
+	}
+	type result struct {
+		RuleID    string
+		Level     string
+		Message   struct{ Text string }
+		Locations []struct {
+			PhysicalLocation struct {
+				ArtifactLocation struct{ URI string }
+				Region           *region
+			}
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		T.Logf("\nTestBuildJSONDiagnostics | expected 2 diagnostics. got:\n%s", stdout.String())
+		T.FailNow()
+	}
+
+	var parseError, undefined result
+	json.Unmarshal([]byte(lines[0]), &parseError)
+	json.Unmarshal([]byte(lines[1]), &undefined)
+
+	location := parseError.Locations[0].PhysicalLocation
+	if parseError.RuleID != "E0101" || parseError.Level != "error" || location.ArtifactLocation.URI != source ||
+		location.Region == nil || *location.Region != (region{3, 9, 3, 10}) {
+		T.Logf("\nTestBuildJSONDiagnostics | unexpected parse error %s", lines[0])
+		T.Fail()
+	}
+	if undefined.RuleID != "E0200" || undefined.Message.Text != "Undefined variable b" {
+		T.Logf("\nTestBuildJSONDiagnostics | unexpected undefined variable error %s", lines[1])
+		T.Fail()
+	}
+}
+
+func TestBuild(T *testing.T) {
+	dir := T.TempDir()
+	output := filepath.Join(dir, "ROM")
+
+	var stdout, stderr bytes.Buffer
+	for _, flag := range []string{"-o", "--output"} {
+		os.Remove(output)
+		code := build([]string{flag, output, "../../examples/print.lo"}, &stdout, &stderr)
+		rom, err := ioutil.ReadFile(output)
+		if code != 0 || err != nil || len(rom) == 0 {
+			T.Logf("\nTestBuild | expected %s to write a ROM. got exit code %d %v %q", flag, code, err, stderr.String())
+			T.Fail()
+		}
+	}
+}
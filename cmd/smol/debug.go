@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/fabulousduck/smol"
+	"github.com/fabulousduck/smol/debugger"
+	"github.com/fabulousduck/smol/diag"
+)
+
+/*
+debugCommand compiles a .lo file with its source map and debugs the ROM.
+commands are read from stdin, see help in the debugger for the list
+
+smol debug file.lo
+*/
+func debugCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: smol debug file.lo")
+		os.Exit(2)
+	}
+
+	filename := args[0]
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	rom, sourceMap, diagnostics := smol.CompileWithMap(string(source), filename, smol.Options{})
+	renderer := diag.NewRenderer(os.Stderr)
+	renderer.Sources[filename] = string(source)
+	renderer.Render(diagnostics)
+	if rom == nil {
+		os.Exit(65)
+	}
+
+	d, err := debugger.NewDebugger(rom, sourceMap, string(source))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	//the prompt is left out when the commands are piped in so the output of a script stays clean
+	prompt := ""
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		prompt = "(smol) "
+	}
+	d.Start(os.Stdin, os.Stdout, prompt)
+}
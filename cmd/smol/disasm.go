@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/fabulousduck/smol/disasm"
+	"github.com/fabulousduck/smol/sourcemap"
+)
+
+/*
+disasmCommand prints the disassembly of a chip-8 ROM
+
+smol disasm [--map ROM.map] ROM
+
+with a source map every line of source is marked in the disassembly
+*/
+func disasmCommand(args []string) {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	mapPath := flags.String("map", "", "source map of the ROM, as written by smol build --map")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: smol disasm [--map ROM.map] ROM")
+		os.Exit(2)
+	}
+
+	rom, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	lines := disasm.Disassemble(rom)
+	if *mapPath == "" {
+		fmt.Print(disasm.Format(lines))
+		return
+	}
+
+	f, err := os.Open(*mapPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	m, err := sourcemap.Read(f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Print(disasm.FormatWithMap(lines, m))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/format"
+)
+
+/*
+fmtCommand formats smol source files
+
+smol fmt [-w | --check] file.lo...
+*/
+func fmtCommand(args []string) {
+	os.Exit(formatFiles(args, os.Stdout, os.Stderr))
+}
+
+/*
+formatFiles does the work of fmtCommand and returns the exit code.
+Without flags the formatted files are printed to stdout.
+-w writes them back to the files that are not formatted yet.
+--check only lists those files and exits with 1 when there are any,
+so it can be used in CI
+*/
+func formatFiles(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write the result back to the file instead of printing it")
+	check := flags.Bool("check", false, "list the files that are not formatted instead of printing them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 || (*write && *check) {
+		fmt.Fprintln(stderr, "usage: smol fmt [-w | --check] file...")
+		return 2
+	}
+
+	code := 0
+	renderer := diag.NewRenderer(stderr)
+	for _, filename := range flags.Args() {
+		source, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			code = 1
+			continue
+		}
+
+		formatted, diagnostics := format.Source(filename, string(source))
+		if diagnostics.HasErrors() {
+			renderer.Sources[filename] = string(source)
+			renderer.Render(diagnostics)
+			code = 65
+			continue
+		}
+
+		switch {
+		case *check:
+			if formatted != string(source) {
+				fmt.Fprintln(stdout, filename)
+				if code == 0 {
+					code = 1
+				}
+			}
+		case *write:
+			if formatted == string(source) {
+				continue
+			}
+			if err := ioutil.WriteFile(filename, []byte(formatted), 0644); err != nil {
+				fmt.Fprintln(stderr, err)
+				code = 1
+			}
+		default:
+			fmt.Fprint(stdout, formatted)
+		}
+	}
+	return code
+}
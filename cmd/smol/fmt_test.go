@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatFiles(T *testing.T) {
+	dir := T.TempDir()
+	messy := filepath.Join(dir, "messy.lo")
+	tidy := filepath.Join(dir, "tidy.lo")
+	ioutil.WriteFile(messy, []byte("def f(a,b):\nprint(a);\nend\n"), 0644)
+	ioutil.WriteFile(tidy, []byte("def f(a, b):\n    print(a)\nend\n"), 0644)
+
+	var stdout, stderr bytes.Buffer
+	if code := formatFiles([]string{"--check", messy, tidy}, &stdout, &stderr); code != 1 || strings.TrimSpace(stdout.String()) != messy {
+		T.Logf("\nTestFormatFiles | expected --check to list only %s and exit with 1. got %d %q", messy, code, stdout.String())
+		T.Fail()
+	}
+
+	stdout.Reset()
+	if code := formatFiles([]string{"-w", messy, tidy}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		T.Logf("\nTestFormatFiles | expected -w to succeed quietly. got %d %q %q", code, stdout.String(), stderr.String())
+		T.Fail()
+	}
+	rewritten, _ := ioutil.ReadFile(messy)
+	original, _ := ioutil.ReadFile(tidy)
+	if !bytes.Equal(rewritten, original) {
+		T.Logf("\nTestFormatFiles | expected -w to rewrite %s. got %q", messy, rewritten)
+		T.Fail()
+	}
+
+	if code := formatFiles([]string{"--check", messy, tidy}, &stdout, &stderr); code != 0 {
+		T.Logf("\nTestFormatFiles | expected --check to pass after -w. got %d", code)
+		T.Fail()
+	}
+}
+
+func TestFormatExamples(T *testing.T) {
+	//fibonacci.lo and expression.lo use syntax smol does not have yet, so they cannot be formatted
+	examples, _ := filepath.Glob(filepath.Join("..", "..", "examples", "*.lo"))
+	files := []string{"--check"}
+	for _, example := range examples {
+		if name := filepath.Base(example); name != "fibonacci.lo" && name != "expression.lo" {
+			files = append(files, example)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := formatFiles(files, &stdout, &stderr); len(files) == 1 || code != 0 {
+		T.Logf("\nTestFormatExamples | expected the examples to be formatted. got %d %q %q", code, stdout.String(), stderr.String())
+		T.Fail()
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fabulousduck/smol/lsp"
+)
+
+/*
+lspCommand runs the language server on stdin and stdout
+so editors can start it as a child process
+
+smol lsp
+*/
+func lspCommand() {
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -2,15 +2,61 @@ package main
 
 import (
 	"flag"
+	"os"
 
 	"github.com/fabulousduck/smol"
+	"github.com/fabulousduck/smol/repl"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "repl":
+			repl.NewRepl(os.Stdin, os.Stdout).Start()
+			return
+		case "run":
+			runCommand(os.Args[2:])
+			return
+		case "disasm":
+			disasmCommand(os.Args[2:])
+			return
+		case "asm":
+			asmCommand(os.Args[2:])
+			return
+		case "build":
+			buildCommand(os.Args[2:])
+			return
+		case "fmt":
+			fmtCommand(os.Args[2:])
+			return
+		case "debug":
+			debugCommand(os.Args[2:])
+			return
+		case "lsp":
+			lspCommand()
+			return
+		}
+	}
+
 	s := smol.NewSmol()
 	filenamePtr := flag.String("file", "", "input file for the interpreter")
+	interpretPtr := flag.Bool("interpret", false, "run the file with the interpreter instead of compiling a ROM")
+	emitPtr := flag.String("emit", "rom", "pipeline stage to output. one of tokens, ast, ir, rom")
+	flag.StringVar(&s.Output, "o", s.Output, "file to write the ROM to")
+	flag.StringVar(&s.Output, "output", s.Output, "file to write the ROM to")
 
 	flag.Parse()
-	s.RunFile(*filenamePtr)
+	switch {
+	case *interpretPtr:
+		s.InterpretFile(*filenamePtr)
+	case *emitPtr != "rom":
+		s.EmitFile(*filenamePtr, *emitPtr, os.Stdout)
+	default:
+		s.RunFile(*filenamePtr)
+	}
 
+	//every diagnostic has been printed by now
+	if s.HadError {
+		os.Exit(65)
+	}
 }
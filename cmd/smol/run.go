@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fabulousduck/smol"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/vm/chip8"
+	"github.com/fabulousduck/smol/vm/gdbremote"
+	"github.com/fabulousduck/smol/vm/terminal"
+)
+
+/*
+runCommand compiles a .lo file and runs the ROM in the built-in chip-8 machine
+
+smol run [--cycles-per-frame N] [--max-cycles N] [--headless] [--dump-screen] [--remote ADDR] file.lo
+
+--headless runs stop after defaultHeadlessCycles instructions unless
+--max-cycles says otherwise, so a program that never halts still ends.
+with --remote the machine does not run by itself but waits for a debugger
+to connect to ADDR, a host:port or unix:path, and drive it
+*/
+//defaultHeadlessCycles is how many instructions a headless run executes when --max-cycles is not given
+const defaultHeadlessCycles = 1000000
+
+func runCommand(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	cyclesPerFrame := flags.Int("cycles-per-frame", 10, "instructions executed every 60hz frame")
+	maxCycles := flags.Int("max-cycles", 0, "stop after this many instructions. 0 means no limit, or 1000000 with --headless")
+	headless := flags.Bool("headless", false, "run without drawing the screen or reading keys")
+	dumpScreen := flags.Bool("dump-screen", false, "print the final screen as text once the run ends")
+	remote := flags.String("remote", "", "wait for a debugger on this host:port or unix:path")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: smol run [flags] file.lo")
+		flags.PrintDefaults()
+		os.Exit(2)
+	}
+
+	machine, err := newMachine(compileFile(flags.Arg(0)), *cyclesPerFrame)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *remote != "":
+		err = serveRemote(machine, *remote)
+	case *headless:
+		if *maxCycles == 0 {
+			*maxCycles = defaultHeadlessCycles
+		}
+		err = machine.Run(*maxCycles)
+		if err == nil && !machine.Halted {
+			fmt.Fprintf(os.Stderr, "stopped after %d instructions without halting\n", machine.Cycles)
+		}
+	default:
+		err = runInTerminal(machine, *cyclesPerFrame, *maxCycles)
+	}
+
+	if *dumpScreen {
+		fmt.Print(machine.ScreenString())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+/*
+serveRemote lets a single debugger drive machine over the GDB remote protocol.
+It returns once the debugger detaches or disconnects
+*/
+func serveRemote(machine *chip8.Machine, address string) error {
+	network := "tcp"
+	if strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	fmt.Fprintf(os.Stderr, "waiting for a debugger on %s\n", listener.Addr())
+	conn, err := listener.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return gdbremote.NewServer(machine).Serve(conn)
+}
+
+//newMachine creates a machine with the ROM loaded whose timers tick once every frame
+func newMachine(rom []byte, cyclesPerFrame int) (*chip8.Machine, error) {
+	machine := chip8.NewMachine()
+	machine.Clock = chip8.NewCycleClock(cyclesPerFrame)
+	return machine, machine.LoadROM(rom)
+}
+
+/*
+compileFile runs a file through the compiler and returns the ROM
+without writing it to disk
+*/
+func compileFile(filename string) []byte {
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	rom, diagnostics := smol.Compile(string(source), filename, smol.Options{})
+	renderer := diag.NewRenderer(os.Stderr)
+	renderer.Sources[filename] = string(source)
+	renderer.Render(diagnostics)
+	if rom == nil {
+		os.Exit(65)
+	}
+	return rom
+}
+
+/*
+runInTerminal runs the machine at 60 frames a second and draws it to the terminal.
+The screen stays up after the program ends until escape or ctrl-c is pressed
+*/
+func runInTerminal(machine *chip8.Machine, cyclesPerFrame int, maxCycles int) error {
+	restore, err := terminal.MakeRaw()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	keypad := terminal.NewKeypad(os.Stdin)
+	machine.Keypad = keypad
+
+	display := terminal.NewDisplay(os.Stdout)
+	display.Clear()
+	display.Draw(machine)
+	defer display.Close()
+
+	frame := time.NewTicker(time.Second / 60)
+	defer frame.Stop()
+
+	for {
+		select {
+		case <-keypad.Quit:
+			return nil
+		case <-frame.C:
+		}
+
+		for i := 0; i < cyclesPerFrame && !machine.Halted; i++ {
+			if maxCycles > 0 && machine.Cycles >= maxCycles {
+				break
+			}
+			if err := machine.Step(); err != nil {
+				return err
+			}
+		}
+		keypad.Frame()
+
+		if machine.DrawFlag {
+			display.Draw(machine)
+			machine.DrawFlag = false
+		}
+	}
+}
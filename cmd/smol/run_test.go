@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//TestGoldenScreens runs every example that has a .screen file next to it and compares the final screen
+func TestGoldenScreens(T *testing.T) {
+	goldens, _ := filepath.Glob("../../examples/*.screen")
+	if len(goldens) == 0 {
+		T.Fatal("no golden screens found")
+	}
+
+	for _, golden := range goldens {
+		expected, _ := ioutil.ReadFile(golden)
+
+		machine, err := newMachine(compileFile(strings.TrimSuffix(golden, ".screen")+".lo"), 10)
+		if err == nil {
+			err = machine.Run(100000)
+		}
+		if err != nil || machine.ScreenString() != string(expected) {
+			T.Logf("\nTestGoldenScreens | %s does not match (error: %v). got:\n%s", filepath.Base(golden), err, machine.ScreenString())
+			T.Fail()
+		}
+	}
+}
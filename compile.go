@@ -0,0 +1,84 @@
+package smol
+
+import (
+	"path/filepath"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/bytecode"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
+	"github.com/fabulousduck/smol/ir"
+	"github.com/fabulousduck/smol/lexer"
+	"github.com/fabulousduck/smol/sourcemap"
+)
+
+//Options changes how Compile treats its source
+type Options struct {
+	//IR reads the source as IR in its text form. Files ending in .ir are always read as IR
+	IR bool
+}
+
+//Diagnostic is a problem found while compiling a program
+type Diagnostic = diag.Diagnostic
+
+/*
+Compile turns src into a chip-8 ROM and returns its bytes.
+Nothing is written to disk, so it can be used to embed the compiler.
+
+Every stage reports the problems it finds and carries on where it can,
+so the diagnostics hold every error in the program, not just the first one.
+When there are errors the ROM is nil
+*/
+func Compile(src string, filename string, opts Options) ([]byte, []Diagnostic) {
+	rom, _, diagnostics := CompileWithMap(src, filename, opts)
+	return rom, diagnostics
+}
+
+/*
+CompileWithMap compiles src like Compile does and also returns the source map of the ROM,
+which maps its addresses back to the lines, variables and functions of src.
+When there are errors both the ROM and the map are nil
+*/
+func CompileWithMap(src string, filename string, opts Options) ([]byte, *sourcemap.Map, []Diagnostic) {
+	var g *ir.Generator
+	diagnostics := new(diag.List)
+
+	if opts.IR || filepath.Ext(filename) == ".ir" {
+		parsed, err := ir.ParseText(filename, src)
+		if err != nil {
+			return nil, nil, []Diagnostic{toDiagnostic(err, filename)}
+		}
+		g = parsed
+	} else {
+		l := lexer.NewLexer(filename, src)
+		l.Diagnostics = diagnostics
+		l.Lex()
+
+		p := ast.NewParser(filename, l.Tokens)
+		p.Diagnostics = diagnostics
+		p.Ast, _ = p.Parse("")
+
+		g = ir.NewGenerator(filename)
+		g.Diagnostics = diagnostics
+		g.Generate(p.Ast)
+	}
+
+	if diagnostics.HasErrors() {
+		return nil, nil, *diagnostics
+	}
+
+	b := bytecode.Init(g, filename)
+	rom, err := b.Build()
+	if err != nil {
+		return nil, nil, append(*diagnostics, toDiagnostic(err, filename))
+	}
+	return rom, b.SourceMap(), *diagnostics
+}
+
+//toDiagnostic turns an error of a stage that does not return diagnostics into one
+func toDiagnostic(err error, filename string) Diagnostic {
+	if d, ok := err.(diag.Diagnostic); ok {
+		return d
+	}
+	return errors.ROMLayoutError(err.Error()).InFile(filename)
+}
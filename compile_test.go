@@ -0,0 +1,241 @@
+package smol
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabulousduck/smol/vm/chip8"
+)
+
+func TestCompile(T *testing.T) {
+	wd, _ := os.Getwd()
+	os.Chdir(T.TempDir())
+	defer os.Chdir(wd)
+
+	rom, diagnostics := Compile("Uint32 x = 10\nplot(x, x)\n", "plot.lo", Options{})
+	expected := []byte{0x60, 0x0A, 0xAE, 0xA0, 0x8E, 0x00, 0x8D, 0x00, 0xDE, 0xD1}
+	if len(diagnostics) > 0 || len(rom) < len(expected) || !bytes.Equal(rom[:len(expected)], expected) {
+		T.Logf("\nTestCompile | got % X %v", rom, diagnostics)
+		T.Fail()
+	}
+
+	if files, _ := ioutil.ReadDir("."); len(files) != 0 {
+		T.Logf("\nTestCompile | Compile wrote %s to disk", files[0].Name())
+		T.Fail()
+	}
+}
+
+func TestCompileWithMap(T *testing.T) {
+	rom, m, diagnostics := CompileWithMap("Uint32 a = 1\ndef inc():\n    a++\nend\ninc()\n", "inc.lo", Options{})
+	if rom == nil || m == nil {
+		T.Fatalf("\nTestCompileWithMap | expected a ROM and a map. got %v", diagnostics)
+	}
+
+	if line, ok := m.Lookup(0x204); !ok || line.Line != 3 || line.Col != 5 {
+		T.Logf("\nTestCompileWithMap | expected a++ at 0x204 to be on 3:5. got %+v", line)
+		T.Fail()
+	}
+	if f, ok := m.Function(0x204); !ok || f.Name != "inc" || f.Start != 0x204 || f.End != 0x208 {
+		T.Logf("\nTestCompileWithMap | expected inc to run from 0x204 to 0x208. got %+v", m.Functions)
+		T.Fail()
+	}
+	if v, ok := m.Variable("a"); !ok || v.Register != 0 || v.Addr != -1 {
+		T.Logf("\nTestCompileWithMap | expected a to be in V0. got %+v", m.Variables)
+		T.Fail()
+	}
+}
+
+func TestCompileFunctionsAndDirectOperations(T *testing.T) {
+	//the body of a function only runs when it is called, and a-- takes one off of a, not of the register holding the amount
+	src := "Uint32 a = 5\nUint32 b = 0\ndef f(x):\n    b++\nend\nf(1)\nf(1)\na--\na--\n"
+	rom, diagnostics := Compile(src, "functions.lo", Options{})
+	if rom == nil {
+		T.Fatalf("\nTestCompileFunctionsAndDirectOperations | %v", diagnostics)
+	}
+
+	machine := chip8.NewMachine()
+	machine.LoadROM(rom)
+	if err := machine.Run(10000); err != nil || !machine.Halted {
+		T.Fatalf("\nTestCompileFunctionsAndDirectOperations | expected the program to halt. got %v at 0x%03X", err, machine.PC)
+	}
+	if a, b := machine.V[0], machine.V[1]; a != 3 || b != 2 {
+		T.Logf("\nTestCompileFunctionsAndDirectOperations | expected a = 3 and b = 2. got a = %d and b = %d", a, b)
+		T.Fail()
+	}
+}
+
+func TestCompileSwappedArguments(T *testing.T) {
+	//the call from f to itself swaps its parameters, which needs y to be read before x is overwritten
+	src := "Uint32 r = 0\ndef f(x, y):\n    eq(r, 0):\n        r++\n        f(y, x)\n    end\nend\nf(1, 2)\n"
+	rom, diagnostics := Compile(src, "swap.lo", Options{})
+	if rom == nil {
+		T.Fatalf("\nTestCompileSwappedArguments | %v", diagnostics)
+	}
+
+	machine := chip8.NewMachine()
+	machine.LoadROM(rom)
+	if err := machine.Run(10000); err != nil || !machine.Halted {
+		T.Fatalf("\nTestCompileSwappedArguments | expected the program to halt. got %v at 0x%03X", err, machine.PC)
+	}
+	//r is in V0, x and y in V1 and V2
+	if r, x, y := machine.V[0], machine.V[1], machine.V[2]; r != 1 || x != 2 || y != 1 {
+		T.Logf("\nTestCompileSwappedArguments | expected r = 1, x = 2 and y = 1. got r = %d, x = %d and y = %d", r, x, y)
+		T.Fail()
+	}
+}
+
+func TestCompileLoops(T *testing.T) {
+	src := "Uint32 a = 0\nUint32 b = 5\nUint32 c = 0\n" +
+		"while(a < b):\n    Uint32 i = 0\n    whileNot(i, 3):\n        i++\n        c++\n    end\n    a++\nend\n" +
+		"while(c > 14):\n    c--\nend\n" +
+		"while(False):\n    a++\nend\n"
+	rom, diagnostics := Compile(src, "loops.lo", Options{})
+	if rom == nil {
+		T.Fatalf("\nTestCompileLoops | %v", diagnostics)
+	}
+
+	machine := chip8.NewMachine()
+	machine.LoadROM(rom)
+	if err := machine.Run(10000); err != nil || !machine.Halted {
+		T.Fatalf("\nTestCompileLoops | expected the program to halt. got %v at 0x%03X", err, machine.PC)
+	}
+	if a, c := machine.V[0], machine.V[2]; a != 5 || c != 14 {
+		T.Logf("\nTestCompileLoops | expected a = 5 and c = 14. got a = %d and c = %d", a, c)
+		T.Fail()
+	}
+}
+
+func TestCompileConditionExpressions(T *testing.T) {
+	src := "def count(from, to):\n    while(from + 1 < to - 1):\n        from++\n    end\nend\n" +
+		"Uint32 a = 2\nUint32 b = 0\ncount(a, 9)\n" +
+		"while(b + b != (a + 4) - 2):\n    b++\nend\n"
+	rom, diagnostics := Compile(src, "expressions.lo", Options{})
+	if rom == nil {
+		T.Fatalf("\nTestCompileConditionExpressions | %v", diagnostics)
+	}
+
+	machine := chip8.NewMachine()
+	machine.LoadROM(rom)
+	if err := machine.Run(10000); err != nil || !machine.Halted {
+		T.Fatalf("\nTestCompileConditionExpressions | expected the program to halt. got %v at 0x%03X", err, machine.PC)
+	}
+	//from and to are in V0 and V1, the temporaries of count in V2 and V3, a and b in V4 and V5
+	if from, a, b := machine.V[0], machine.V[4], machine.V[5]; from != 7 || a != 2 || b != 2 {
+		T.Logf("\nTestCompileConditionExpressions | expected from = 7, a = 2 and b = 2. got from = %d, a = %d and b = %d", from, a, b)
+		T.Fail()
+	}
+}
+
+func TestCompileIf(T *testing.T) {
+	src := "Uint32 a = 3\nUint32 b = 7\nUint32 two = 2\nUint32 three = 3\nUint32 r = 0\nUint32 s = 0\n" +
+		"if(a >= b):\n    set r b;\nelif(a == 3):\n    set r two;\nelse:\n    set r three;\nend\n" +
+		"if(b <= 7):\n    s++\nend\n" +
+		"if(a != b):\n    s++\nend\n" +
+		"if(b < a):\n    s++\nelse:\n    s++\n    s++\nend\n" +
+		"if(9 >= a):\n    s++\nend\n" +
+		"if(a > b):\n    s++\nend\n"
+	rom, diagnostics := Compile(src, "if.lo", Options{})
+	if rom == nil {
+		T.Fatalf("\nTestCompileIf | %v", diagnostics)
+	}
+
+	machine := chip8.NewMachine()
+	machine.LoadROM(rom)
+	if err := machine.Run(10000); err != nil || !machine.Halted {
+		T.Fatalf("\nTestCompileIf | expected the program to halt. got %v at 0x%03X", err, machine.PC)
+	}
+	if r, s := machine.V[4], machine.V[5]; r != 2 || s != 5 {
+		T.Logf("\nTestCompileIf | expected r = 2 and s = 5. got r = %d and s = %d", r, s)
+		T.Fail()
+	}
+
+	_, diagnostics = Compile("Uint32 a = 1\nif(a):\n    a++\nelse:\n    a--\nelse:\n    a++\nend\n", "else.lo", Options{})
+	if len(diagnostics) != 1 || diagnostics[0].Pos.Line != 6 || diagnostics[0].Message != "else without if" {
+		T.Logf("\nTestCompileIf | expected a second else to be reported. got %v", diagnostics)
+		T.Fail()
+	}
+}
+
+func TestCompileComparisonBlocks(T *testing.T) {
+	src := "Uint32 a = 4\nUint32 b = 9\nUint32 s = 0\n" +
+		"eq(a, 4):\n    s++\nend\neq(a, b):\n    s++\nend\n" +
+		"neq(a, b):\n    s++\nend\nneq(4, a):\n    s++\nend\n" +
+		"gt(b, a):\n    s++\nend\ngt(a, a):\n    s++\nend\n" +
+		"lt(a, 5):\n    s++\nend\nlt(b, a):\n    s++\nend\n"
+	rom, diagnostics := Compile(src, "blocks.lo", Options{})
+	if rom == nil {
+		T.Fatalf("\nTestCompileComparisonBlocks | %v", diagnostics)
+	}
+
+	machine := chip8.NewMachine()
+	machine.LoadROM(rom)
+	if err := machine.Run(10000); err != nil || !machine.Halted {
+		T.Fatalf("\nTestCompileComparisonBlocks | expected the program to halt. got %v at 0x%03X", err, machine.PC)
+	}
+	if s := machine.V[2]; s != 4 {
+		T.Logf("\nTestCompileComparisonBlocks | expected the body of 4 blocks to run. got %d", s)
+		T.Fail()
+	}
+}
+
+func TestCompileDiagnostics(T *testing.T) {
+	rom, diagnostics := Compile("Jump nowhere\n", "test.ir", Options{})
+	if rom != nil || len(diagnostics) != 1 || diagnostics[0].Error() != "test.ir:1: undefined label nowhere" || diagnostics[0].Pos.Line != 1 {
+		T.Logf("\nTestCompileDiagnostics | got % X %v", rom, diagnostics)
+		T.Fail()
+	}
+
+	_, diagnostics = Compile("RET\n", "test.s", Options{IR: true})
+	if len(diagnostics) != 0 {
+		T.Logf("\nTestCompileDiagnostics | Options.IR was ignored: %v", diagnostics)
+		T.Fail()
+	}
+}
+
+func TestCompileReportsEveryError(T *testing.T) {
+	src := "Uint32 a = 1 $\nplot(a, )\nplot(c, 1)\nplot(a, a)\n"
+	rom, diagnostics := Compile(src, "errors.lo", Options{})
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{1, "undefined symbol \"$\" used"},
+		{2, "expected one of [character, string, integer]. got right_parenthesis"},
+		{3, "Undefined variable c"},
+	}
+	if rom != nil || len(diagnostics) != len(expected) {
+		T.Logf("\nTestCompileReportsEveryError | expected %d diagnostics. got %v", len(expected), diagnostics)
+		T.FailNow()
+	}
+	for i, e := range expected {
+		d := diagnostics[i]
+		if d.Severity != "error" || d.Pos.File != "errors.lo" || d.Pos.Line != e.line || d.Message != e.message {
+			T.Logf("\nTestCompileReportsEveryError | expected %s:%d: %s. got %#v", "errors.lo", e.line, e.message, d)
+			T.Fail()
+		}
+	}
+}
+
+func TestRunFileErrors(T *testing.T) {
+	dir := T.TempDir()
+
+	s := NewSmol()
+	s.RunFile(filepath.Join(dir, "missing.lo"))
+	if !s.HadError {
+		T.Logf("\nTestRunFileErrors | expected a file that does not exist to be reported")
+		T.Fail()
+	}
+
+	//a directory cannot be written to as if it were a file
+	s = NewSmol()
+	s.Output = dir
+	s.Run("Uint32 a = 1\n", "write.lo")
+	if !s.HadError {
+		T.Logf("\nTestRunFileErrors | expected a ROM that cannot be written to be reported")
+		T.Fail()
+	}
+}
@@ -0,0 +1,158 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fabulousduck/smol/disasm"
+)
+
+//help lists the commands Start understands
+const help = `break LOCATION   stop at a line, file.lo:line, function or 0xADDR (b)
+delete ID        remove a breakpoint (d)
+breakpoints      list the breakpoints
+continue         run until a breakpoint or the end of the program (c)
+step             run to the next line of source, into calls (s)
+next             run to the next line of source, over calls (n)
+stepi            execute a single opcode (si)
+print NAME       print a variable (p)
+backtrace        show the functions that are being run (bt)
+registers        show the registers of the machine (regs)
+where            show where the machine is
+restart          start the program over
+quit             stop debugging (q)
+`
+
+/*
+Start reads commands from in, one on every line, and writes what they do to out
+until the input runs out or quit is entered.
+Every line of output is plain text, problems start with "error: ",
+so a session can be scripted by piping commands in.
+Prompt is written before every command when it is set
+*/
+func (d *Debugger) Start(in io.Reader, out io.Writer, prompt string) {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, prompt)
+		if !scanner.Scan() {
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "q" {
+			return
+		}
+		if err := d.command(fields[0], fields[1:], out); err != nil {
+			fmt.Fprintf(out, "error: %s\n", err)
+		}
+	}
+}
+
+func (d *Debugger) command(name string, args []string, out io.Writer) error {
+	switch name {
+	case "break", "b":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: break LOCATION")
+		}
+		b, err := d.Break(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "breakpoint %d at 0x%03X, %s\n", b.ID, b.Addr, d.Where(b.Addr))
+	case "delete", "d":
+		id := -1
+		if len(args) == 1 {
+			id, _ = strconv.Atoi(args[0])
+		}
+		if !d.Delete(id) {
+			return fmt.Errorf("no breakpoint %s", strings.Join(args, " "))
+		}
+	case "breakpoints":
+		for _, b := range d.Breakpoints {
+			fmt.Fprintf(out, "%d  0x%03X  %s\n", b.ID, b.Addr, d.Where(b.Addr))
+		}
+	case "continue", "c":
+		return d.stopped(d.Continue(), out, false)
+	case "step", "s":
+		return d.stopped(d.StepLine(false), out, false)
+	case "next", "n":
+		return d.stopped(d.StepLine(true), out, false)
+	case "stepi", "si":
+		return d.stopped(d.StepInstruction(), out, true)
+	case "print", "p":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: print NAME")
+		}
+		value, location, err := d.Variable(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s = %d (%s)\n", args[0], value, location)
+	case "backtrace", "bt":
+		for i, frame := range d.Backtrace() {
+			fmt.Fprintf(out, "#%d  %s\n", i, d.Where(frame.Addr))
+		}
+	case "registers", "regs":
+		m := d.Machine
+		for i, v := range m.V {
+			fmt.Fprintf(out, "V%X = 0x%02X\n", i, v)
+		}
+		fmt.Fprintf(out, "I = 0x%03X\nPC = 0x%03X\nSP = %d\n", m.I, m.PC, m.SP)
+	case "where":
+		d.location(out, true)
+	case "restart":
+		if err := d.Restart(); err != nil {
+			return err
+		}
+		d.location(out, false)
+	case "help":
+		fmt.Fprint(out, help)
+	default:
+		return fmt.Errorf("unknown command %s. try help", name)
+	}
+	return nil
+}
+
+//stopped reports where the machine stopped after running it
+func (d *Debugger) stopped(err error, out io.Writer, instruction bool) error {
+	if err != nil {
+		return err
+	}
+	if d.Machine.Halted {
+		fmt.Fprintf(out, "program halted at 0x%03X after %d instructions\n", d.Machine.PC, d.Machine.Cycles)
+		return nil
+	}
+	if b := d.BreakpointAt(int(d.Machine.PC)); b != nil {
+		fmt.Fprintf(out, "breakpoint %d, ", b.ID)
+	}
+	d.location(out, instruction)
+	return nil
+}
+
+/*
+location writes where the machine is followed by the line of source it is at.
+with instruction set the opcode that is executed next is written as well
+*/
+func (d *Debugger) location(out io.Writer, instruction bool) {
+	pc := int(d.Machine.PC)
+	fmt.Fprintln(out, d.Where(pc))
+
+	if line, ok := d.Map.Lookup(pc); ok {
+		if text, ok := d.SourceLine(line.Line); ok {
+			fmt.Fprintf(out, "%d | %s\n", line.Line, text)
+		}
+	}
+	if instruction {
+		opcode := d.Machine.Opcode()
+		text, ok := disasm.Decode(opcode)
+		if !ok {
+			text = "???"
+		}
+		fmt.Fprintf(out, "=> 0x%03X  %04X  %s\n", pc, opcode, text)
+	}
+}
@@ -0,0 +1,247 @@
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fabulousduck/smol/sourcemap"
+	"github.com/fabulousduck/smol/vm/chip8"
+)
+
+//DefaultMaxCycles is how many instructions continue, step and next run at most before giving control back
+const DefaultMaxCycles = 10000000
+
+//programFrame is the name of the frame of code that is not in a function
+const programFrame = "program"
+
+/*
+Debugger runs a ROM in the chip-8 machine one piece at a time.
+It uses the source map of the ROM to stop at lines of source,
+find variables and tell which function the machine is in
+*/
+type Debugger struct {
+	Machine     *chip8.Machine
+	Map         *sourcemap.Map
+	Breakpoints []Breakpoint
+	MaxCycles   int
+	rom         []byte
+	source      []string
+	nextID      int
+}
+
+//Breakpoint stops the machine before the opcode at Addr is executed
+type Breakpoint struct {
+	ID, Addr int
+}
+
+//Frame is a function that is being run. Addr is where it is, for callers that is the call
+type Frame struct {
+	Function string
+	Addr     int
+}
+
+//NewDebugger loads rom in a new machine. source is the program the map points into
+func NewDebugger(rom []byte, m *sourcemap.Map, source string) (*Debugger, error) {
+	d := new(Debugger)
+	d.Machine = chip8.NewMachine()
+	d.Map = m
+	d.rom = rom
+	d.source = strings.Split(source, "\n")
+	d.MaxCycles = DefaultMaxCycles
+	d.nextID = 1
+	return d, d.Machine.LoadROM(rom)
+}
+
+//Restart puts the machine back at the start of the program. breakpoints are kept
+func (d *Debugger) Restart() error {
+	d.Machine.Reset()
+	return d.Machine.LoadROM(d.rom)
+}
+
+/*
+Break sets a breakpoint at location, which is one of
+
+	12          the first opcode of line 12
+	file.lo:12  the same
+	add         the start of the function add
+	0x204       an address
+*/
+func (d *Debugger) Break(location string) (Breakpoint, error) {
+	addr, err := d.resolve(location)
+	if err != nil {
+		return Breakpoint{}, err
+	}
+	b := Breakpoint{ID: d.nextID, Addr: addr}
+	d.nextID++
+	d.Breakpoints = append(d.Breakpoints, b)
+	return b, nil
+}
+
+func (d *Debugger) resolve(location string) (int, error) {
+	if strings.HasPrefix(location, "0x") {
+		addr, err := strconv.ParseInt(location[2:], 16, 0)
+		if err != nil || addr < chip8.ProgramStart || addr >= chip8.MemorySize {
+			return 0, fmt.Errorf("invalid address %s", location)
+		}
+		return int(addr), nil
+	}
+
+	lineNumber := location
+	if i := strings.LastIndex(location, ":"); i >= 0 {
+		lineNumber = location[i+1:]
+	}
+	if line, err := strconv.Atoi(lineNumber); err == nil {
+		addresses := d.Map.Addresses(line)
+		if len(addresses) == 0 {
+			return 0, fmt.Errorf("no code was generated for line %d", line)
+		}
+		return addresses[0], nil
+	}
+
+	for _, function := range d.Map.Functions {
+		if function.Name == location {
+			return function.Start, nil
+		}
+	}
+	return 0, fmt.Errorf("no line or function %s", location)
+}
+
+//Delete removes the breakpoint with id. it returns false when there is no such breakpoint
+func (d *Debugger) Delete(id int) bool {
+	for i, b := range d.Breakpoints {
+		if b.ID == id {
+			d.Breakpoints = append(d.Breakpoints[:i], d.Breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//BreakpointAt returns the breakpoint at addr, nil when there is none
+func (d *Debugger) BreakpointAt(addr int) *Breakpoint {
+	for i := range d.Breakpoints {
+		if d.Breakpoints[i].Addr == addr {
+			return &d.Breakpoints[i]
+		}
+	}
+	return nil
+}
+
+//StepInstruction executes a single opcode
+func (d *Debugger) StepInstruction() error {
+	if d.Machine.Halted {
+		return fmt.Errorf("the program has halted")
+	}
+	return d.Machine.Step()
+}
+
+/*
+StepLine runs until the machine reaches the start of a line of source.
+With over set calls are run to their end instead of stepped into.
+It stops early at a breakpoint, when the program halts
+or after MaxCycles instructions
+*/
+func (d *Debugger) StepLine(over bool) error {
+	depth := d.Machine.SP
+	for start := d.Machine.Cycles; ; {
+		if err := d.limit(start); err != nil {
+			return err
+		}
+		if err := d.StepInstruction(); err != nil {
+			return err
+		}
+		pc := int(d.Machine.PC)
+		if d.Machine.Halted || d.BreakpointAt(pc) != nil {
+			return nil
+		}
+		if over && d.Machine.SP > depth {
+			continue
+		}
+		if line, ok := d.Map.Lookup(pc); ok && line.Addr == pc {
+			return nil
+		}
+	}
+}
+
+//Continue runs until a breakpoint is reached, the program halts or MaxCycles instructions have run
+func (d *Debugger) Continue() error {
+	for start := d.Machine.Cycles; ; {
+		if err := d.limit(start); err != nil {
+			return err
+		}
+		if err := d.StepInstruction(); err != nil {
+			return err
+		}
+		if d.Machine.Halted || d.BreakpointAt(int(d.Machine.PC)) != nil {
+			return nil
+		}
+	}
+}
+
+//limit returns an error once MaxCycles instructions have run since start
+func (d *Debugger) limit(start int) error {
+	if d.MaxCycles > 0 && d.Machine.Cycles-start >= d.MaxCycles {
+		return fmt.Errorf("stopped after %d instructions at %s", d.MaxCycles, d.Where(int(d.Machine.PC)))
+	}
+	return nil
+}
+
+/*
+Variable returns the value of a variable of the program
+and where it is kept, like V0 or 0xEA0.
+Inside a function its own variables and parameters are found first
+*/
+func (d *Debugger) Variable(name string) (int, string, error) {
+	var v sourcemap.Variable
+	ok := false
+	if function, inFunction := d.Map.Function(int(d.Machine.PC)); inFunction {
+		v, ok = d.Map.Variable(function.Name + "." + name)
+	}
+	if !ok {
+		v, ok = d.Map.Variable(name)
+	}
+	switch {
+	case !ok:
+		return 0, "", fmt.Errorf("no variable named %s", name)
+	case v.Register >= 0:
+		return int(d.Machine.V[v.Register]), fmt.Sprintf("V%X", v.Register), nil
+	}
+	return int(d.Machine.ReadMemory(uint16(v.Addr))), fmt.Sprintf("0x%03X", v.Addr), nil
+}
+
+/*
+Backtrace returns the functions that are being run, the innermost first.
+The return addresses on the chip-8 stack point just past the calls
+*/
+func (d *Debugger) Backtrace() []Frame {
+	frames := []Frame{d.frame(int(d.Machine.PC))}
+	for i := int(d.Machine.SP) - 1; i >= 0; i-- {
+		frames = append(frames, d.frame(int(d.Machine.Stack[i])-2))
+	}
+	return frames
+}
+
+func (d *Debugger) frame(addr int) Frame {
+	if function, ok := d.Map.Function(addr); ok {
+		return Frame{Function: function.Name, Addr: addr}
+	}
+	return Frame{Function: programFrame, Addr: addr}
+}
+
+//Where describes addr as the function it is in and the source it was compiled from
+func (d *Debugger) Where(addr int) string {
+	function := d.frame(addr).Function
+	if line, ok := d.Map.Lookup(addr); ok {
+		return fmt.Sprintf("%s at %s:%d:%d", function, d.Map.File, line.Line, line.Col)
+	}
+	return fmt.Sprintf("%s at 0x%03X", function, addr)
+}
+
+//SourceLine returns the text of a line of the program
+func (d *Debugger) SourceLine(line int) (string, bool) {
+	if line < 1 || line > len(d.source) {
+		return "", false
+	}
+	return strings.TrimRight(d.source[line-1], "\r"), true
+}
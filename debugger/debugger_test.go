@@ -0,0 +1,141 @@
+package debugger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fabulousduck/smol"
+)
+
+const program = `Uint32 a = 1
+def inc():
+    a++
+end
+inc()
+inc()
+plot(a, a)
+`
+
+func newTestDebugger(T *testing.T) *Debugger {
+	rom, m, diagnostics := smol.CompileWithMap(program, "inc.lo", smol.Options{})
+	if rom == nil {
+		T.Fatalf("\nTestDebugger | the program does not compile: %v", diagnostics)
+	}
+	d, err := NewDebugger(rom, m, program)
+	if err != nil {
+		T.Fatalf("\nTestDebugger | %s", err)
+	}
+	return d
+}
+
+func TestSession(T *testing.T) {
+	script := `break inc
+b 6
+c
+bt
+p a
+n
+n
+p a
+si
+print b
+jump
+c
+c
+`
+	expected := `breakpoint 1 at 0x204, inc at inc.lo:3:5
+breakpoint 2 at 0x20A, program at inc.lo:6:1
+breakpoint 1, inc at inc.lo:3:5
+3 |     a++
+#0  inc at inc.lo:3:5
+#1  program at inc.lo:5:1
+a = 1 (V0)
+inc at inc.lo:2:1
+2 | def inc():
+breakpoint 2, program at inc.lo:6:1
+6 | inc()
+a = 2 (V0)
+breakpoint 1, inc at inc.lo:3:5
+3 |     a++
+=> 0x204  7001  ADD V0, 0x01
+error: no variable named b
+error: unknown command jump. try help
+program halted at 0x214 after 13 instructions
+error: the program has halted
+`
+	var out bytes.Buffer
+	d := newTestDebugger(T)
+	d.Start(strings.NewReader(script), &out, "")
+
+	if out.String() != expected {
+		T.Logf("\nTestSession | expected:\n%s\ngot:\n%s", expected, out.String())
+		T.Fail()
+	}
+}
+
+func TestStepOverCalls(T *testing.T) {
+	d := newTestDebugger(T)
+	lines := []int{}
+	for !d.Machine.Halted {
+		if err := d.StepLine(true); err != nil {
+			T.Fatalf("\nTestStepOverCalls | %s", err)
+		}
+		if line, ok := d.Map.Lookup(int(d.Machine.PC)); ok && !d.Machine.Halted {
+			lines = append(lines, line.Line)
+		}
+	}
+
+	//the jump over the body of inc is part of line 2
+	expected := []int{2, 5, 6, 7}
+	if len(lines) != len(expected) {
+		T.Fatalf("\nTestStepOverCalls | expected to stop on lines %v. got %v", expected, lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			T.Logf("\nTestStepOverCalls | expected to stop on lines %v. got %v", expected, lines)
+			T.Fail()
+		}
+	}
+	if value, _, _ := d.Variable("a"); value != 3 {
+		T.Logf("\nTestStepOverCalls | expected a to be 3 at the end. got %d", value)
+		T.Fail()
+	}
+}
+
+func TestContinueLimit(T *testing.T) {
+	d := newTestDebugger(T)
+	d.MaxCycles = 3
+
+	if err := d.Continue(); err == nil || d.Machine.Cycles != 3 {
+		T.Logf("\nTestContinueLimit | expected continue to stop after 3 instructions. got %d (error: %v)", d.Machine.Cycles, err)
+		T.Fail()
+	}
+	if d.StepLine(true); d.Machine.Cycles > 6 {
+		T.Logf("\nTestContinueLimit | expected next to stop after 3 more instructions. got %d", d.Machine.Cycles)
+		T.Fail()
+	}
+}
+
+func TestScopedVariables(T *testing.T) {
+	source := "Uint32 a = 1\ndef add(x):\n    Uint32 a = 2\n    x++\nend\nadd(a)\n"
+	rom, m, diagnostics := smol.CompileWithMap(source, "add.lo", smol.Options{})
+	if rom == nil {
+		T.Fatalf("\nTestScopedVariables | the program does not compile: %v", diagnostics)
+	}
+	d, _ := NewDebugger(rom, m, source)
+	d.Break("4")
+	d.Continue()
+
+	for name, expected := range map[string]string{"x": "V1", "a": "V2", "add.a": "V2"} {
+		if _, location, err := d.Variable(name); err != nil || location != expected {
+			T.Logf("\nTestScopedVariables | expected %s to be in %s inside add. got %s (error: %v)", name, expected, location, err)
+			T.Fail()
+		}
+	}
+	d.Continue()
+	if _, location, _ := d.Variable("a"); location != "V0" {
+		T.Logf("\nTestScopedVariables | expected a to be in V0 outside of add. got %s", location)
+		T.Fail()
+	}
+}
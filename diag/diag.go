@@ -0,0 +1,152 @@
+/*
+Package diag holds the problems the compiler finds in a program.
+
+Every stage reports what it finds as a Diagnostic on a shared List
+and carries on where it can, so a single compile reports every error
+instead of stopping at the first one
+*/
+package diag
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Severity tells how bad a diagnostic is
+type Severity string
+
+//the severities a diagnostic can have. only errors stop a program from being compiled
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Note    Severity = "note"
+)
+
+/*
+Pos is a place in a source file.
+Line and Col start at 1, a zero means it is not known
+*/
+type Pos struct {
+	File      string
+	Line, Col int
+}
+
+func (p Pos) String() string {
+	location := p.File
+	if p.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, p.Line)
+		if p.Col > 0 {
+			location = fmt.Sprintf("%s:%d", location, p.Col)
+		}
+	}
+	return location
+}
+
+//Span is the part of the source a diagnostic is about. End is exclusive
+type Span struct {
+	Start, End Pos
+}
+
+/*
+Diagnostic is a single problem found in a program.
+Code is a short stable name for the kind of problem and
+Notes hold extra hints on how to fix it
+*/
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Pos      Pos
+	Span     Span
+	Message  string
+	Notes    []string
+}
+
+//Errorf creates an error diagnostic that does not point anywhere yet
+func Errorf(format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: Error, Message: fmt.Sprintf(format, args...)}
+}
+
+//At returns a copy of d that points at pos
+func (d Diagnostic) At(pos Pos) Diagnostic {
+	d.Pos = pos
+	d.Span = Span{pos, pos}
+	return d
+}
+
+//Between returns a copy of d that points at the source from start up to end
+func (d Diagnostic) Between(start Pos, end Pos) Diagnostic {
+	d.Pos = start
+	d.Span = Span{start, end}
+	return d
+}
+
+//InFile returns a copy of d with its file set when it does not have one yet
+func (d Diagnostic) InFile(file string) Diagnostic {
+	if d.Pos.File == "" {
+		d.Pos.File = file
+	}
+	if d.Span.Start.File == "" {
+		d.Span.Start.File = file
+		d.Span.End.File = file
+	}
+	return d
+}
+
+//WithNote returns a copy of d with note added to its notes
+func (d Diagnostic) WithNote(note string) Diagnostic {
+	d.Notes = append(append([]string{}, d.Notes...), note)
+	return d
+}
+
+//WithHelp adds a note that tells how to fix the problem. it is shown as help instead of note
+func (d Diagnostic) WithHelp(help string) Diagnostic {
+	return d.WithNote("help: " + help)
+}
+
+//Error gives the location and message of d so it can be used as an error
+func (d Diagnostic) Error() string {
+	if location := d.Pos.String(); location != "" {
+		return location + ": " + d.Message
+	}
+	return d.Message
+}
+
+//String gives d the way the command line prints it, notes included
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if location := d.Pos.String(); location != "" {
+		b.WriteString(location + ": ")
+	}
+	fmt.Fprintf(&b, "%s: %s", d.label(), d.Message)
+	for _, note := range d.Notes {
+		label, text := splitNote(note)
+		fmt.Fprintf(&b, "\n  %s: %s", label, text)
+	}
+	return b.String()
+}
+
+//label gives the severity of d with its code, like error[E0200]
+func (d Diagnostic) label() string {
+	if d.Code == "" {
+		return string(d.Severity)
+	}
+	return fmt.Sprintf("%s[%s]", d.Severity, d.Code)
+}
+
+//List collects the diagnostics of every stage of a compile
+type List []Diagnostic
+
+//Add appends d to the list
+func (l *List) Add(d Diagnostic) {
+	*l = append(*l, d)
+}
+
+//HasErrors reports whether any diagnostic in the list is an error
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package diag
+
+import "testing"
+
+func TestString(T *testing.T) {
+	testCases := []struct {
+		d        Diagnostic
+		err, str string
+	}{
+		{Errorf("no position"), "no position", "error: no position"},
+		{Errorf("in a file").InFile("a.lo"), "a.lo: in a file", "a.lo: error: in a file"},
+		{Errorf("on a line").At(Pos{"a.lo", 3, 0}), "a.lo:3: on a line", "a.lo:3: error: on a line"},
+		{
+			Errorf("bad %s", "token").Between(Pos{"a.lo", 3, 5}, Pos{"a.lo", 3, 8}).WithNote("try this"),
+			"a.lo:3:5: bad token",
+			"a.lo:3:5: error: bad token\n  note: try this",
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.d.Error() != tc.err || tc.d.String() != tc.str {
+			T.Logf("\nTestString | expected %q and %q. got %q and %q", tc.err, tc.str, tc.d.Error(), tc.d.String())
+			T.Fail()
+		}
+	}
+}
+
+func TestHasErrors(T *testing.T) {
+	list := new(List)
+	list.Add(Diagnostic{Severity: Warning, Message: "unused"})
+	if list.HasErrors() {
+		T.Logf("\nTestHasErrors | a warning counted as an error")
+		T.Fail()
+	}
+
+	list.Add(Errorf("broken"))
+	if !list.HasErrors() || len(*list) != 2 {
+		T.Logf("\nTestHasErrors | expected 2 diagnostics with an error. got %v", *list)
+		T.Fail()
+	}
+}
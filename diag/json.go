@@ -0,0 +1,90 @@
+package diag
+
+import (
+	"encoding/json"
+	"io"
+)
+
+/*
+jsonResult is a diagnostic in the shape of a SARIF result.
+Only the fields editors and CI tools read are filled in
+*/
+type jsonResult struct {
+	RuleID     string          `json:"ruleId,omitempty"`
+	Level      Severity        `json:"level"`
+	Message    jsonMessage     `json:"message"`
+	Locations  []jsonLocation  `json:"locations"`
+	Properties *jsonProperties `json:"properties,omitempty"`
+}
+
+type jsonMessage struct {
+	Text string `json:"text"`
+}
+
+type jsonLocation struct {
+	PhysicalLocation jsonPhysicalLocation `json:"physicalLocation"`
+}
+
+type jsonPhysicalLocation struct {
+	ArtifactLocation jsonArtifact `json:"artifactLocation"`
+	Region           *jsonRegion  `json:"region,omitempty"`
+}
+
+type jsonArtifact struct {
+	URI string `json:"uri"`
+}
+
+//jsonRegion is where in the file the diagnostic is. like Span the end column is exclusive
+type jsonRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type jsonProperties struct {
+	Notes []string `json:"notes"`
+}
+
+/*
+WriteJSON writes every diagnostic as a JSON object on a line of its own.
+The objects have the shape of a SARIF result
+
+	{"ruleId":"E0200","level":"error","message":{"text":"Undefined variable b"},
+	 "locations":[{"physicalLocation":{"artifactLocation":{"uri":"a.lo"},
+	 "region":{"startLine":2,"startColumn":9,"endLine":2,"endColumn":10}}}]}
+
+a region is only given when the line is known
+*/
+func WriteJSON(w io.Writer, diagnostics List) error {
+	encoder := json.NewEncoder(w)
+	for _, d := range diagnostics {
+		if err := encoder.Encode(toJSON(d)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toJSON(d Diagnostic) jsonResult {
+	location := jsonLocation{jsonPhysicalLocation{ArtifactLocation: jsonArtifact{d.Pos.File}}}
+	if d.Pos.Line > 0 {
+		region := &jsonRegion{StartLine: d.Pos.Line, StartColumn: d.Pos.Col}
+		if d.Span.End.Line >= d.Pos.Line {
+			region.EndLine = d.Span.End.Line
+			region.EndColumn = d.Span.End.Col
+		}
+		location.PhysicalLocation.Region = region
+	}
+
+	result := jsonResult{
+		RuleID:    d.Code,
+		Level:     d.Severity,
+		Message:   jsonMessage{d.Message},
+		Locations: []jsonLocation{location},
+	}
+	if len(d.Notes) > 0 {
+		result.Properties = &jsonProperties{d.Notes}
+	}
+	return result
+}
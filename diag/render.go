@@ -0,0 +1,163 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//the escape codes used when rendering in color
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	red    = "\x1b[1;31m"
+	yellow = "\x1b[1;33m"
+	blue   = "\x1b[1;34m"
+	cyan   = "\x1b[1;36m"
+)
+
+/*
+Renderer prints diagnostics the way rustc does.
+The line a diagnostic points at is printed under it with its span underlined
+
+	error[E0101]: expected one of [character, string, integer]. got right_parenthesis
+	 --> plot.lo:2:9
+	  |
+	2 | plot(a, )
+	  |         ^
+	  = help: plot takes an x and a y
+
+Sources holds the source of every file by name,
+diagnostics in files that are not in it are printed without their line
+*/
+type Renderer struct {
+	Out     io.Writer
+	Color   bool
+	Sources map[string]string
+}
+
+/*
+NewRenderer creates a renderer that writes to out.
+Color is turned on when out is a terminal and NO_COLOR is not set
+*/
+func NewRenderer(out io.Writer) *Renderer {
+	r := new(Renderer)
+	r.Out = out
+	r.Sources = make(map[string]string)
+	if f, ok := out.(*os.File); ok && os.Getenv("NO_COLOR") == "" {
+		r.Color = IsTerminal(f)
+	}
+	return r
+}
+
+//IsTerminal reports whether f is a terminal rather than a file or a pipe
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+//Render prints every diagnostic in the list
+func (r *Renderer) Render(diagnostics List) {
+	for _, d := range diagnostics {
+		r.RenderOne(d)
+	}
+}
+
+//RenderOne prints a single diagnostic with its source line and notes
+func (r *Renderer) RenderOne(d Diagnostic) {
+	fmt.Fprintf(r.Out, "%s: %s\n", r.paint(severityColor(d.Severity), d.label()), r.paint(bold, d.Message))
+
+	line, ok := r.sourceLine(d.Pos)
+	number := strconv.Itoa(d.Pos.Line)
+	gutter := strings.Repeat(" ", len(number))
+	if location := d.Pos.String(); location != "" {
+		fmt.Fprintf(r.Out, "%s%s %s\n", gutter, r.paint(blue, "-->"), location)
+	}
+
+	if ok {
+		bar := r.paint(blue, "|")
+		fmt.Fprintf(r.Out, "%s %s\n", gutter, bar)
+		fmt.Fprintf(r.Out, "%s %s %s\n", r.paint(blue, number), bar, line)
+		if d.Pos.Col > 0 {
+			fmt.Fprintf(r.Out, "%s %s %s\n", gutter, bar, r.paint(severityColor(d.Severity), underline(line, d.Span, d.Pos)))
+		}
+	}
+
+	for _, note := range d.Notes {
+		label, text := splitNote(note)
+		fmt.Fprintf(r.Out, "%s %s %s: %s\n", gutter, r.paint(blue, "="), r.paint(bold, label), text)
+	}
+	fmt.Fprintln(r.Out)
+}
+
+//sourceLine returns the line pos points at, if its source is known
+func (r *Renderer) sourceLine(pos Pos) (string, bool) {
+	source, ok := r.Sources[pos.File]
+	if !ok || pos.Line < 1 {
+		return "", false
+	}
+	lines := strings.Split(source, "\n")
+	if pos.Line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[pos.Line-1], "\r"), true
+}
+
+/*
+underline puts carets under the part of line the span covers.
+tabs before the span are kept so the carets line up with the source.
+A span that is empty or runs past the line gets at least one caret
+*/
+func underline(line string, span Span, pos Pos) string {
+	start := pos.Col - 1
+	end := start + 1
+	if span.End.Line == pos.Line && span.End.Col-1 > start {
+		end = span.End.Col - 1
+	} else if span.End.Line > pos.Line {
+		end = len(line)
+	}
+	if end > len(line) && start < len(line) {
+		end = len(line)
+	}
+	if end <= start {
+		end = start + 1
+	}
+
+	var b strings.Builder
+	for i := 0; i < start; i++ {
+		if i < len(line) && line[i] == '\t' {
+			b.WriteByte('\t')
+			continue
+		}
+		b.WriteByte(' ')
+	}
+	b.WriteString(strings.Repeat("^", end-start))
+	return b.String()
+}
+
+func (r *Renderer) paint(color string, text string) string {
+	if !r.Color {
+		return text
+	}
+	return color + text + reset
+}
+
+func severityColor(severity Severity) string {
+	switch severity {
+	case Error:
+		return red
+	case Warning:
+		return yellow
+	}
+	return cyan
+}
+
+//splitNote separates the label a note was added with from its text. notes without one are plain notes
+func splitNote(note string) (string, string) {
+	if strings.HasPrefix(note, "help: ") {
+		return "help", strings.TrimPrefix(note, "help: ")
+	}
+	return "note", note
+}
@@ -0,0 +1,63 @@
+package diag
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRender(T *testing.T) {
+	source := "Uint32 a = 1\n\tplot(a, )\n"
+	testCases := []struct {
+		name     string
+		d        Diagnostic
+		expected string
+	}{
+		{
+			"span",
+			Errorf("bad name").Between(Pos{"a.lo", 1, 8}, Pos{"a.lo", 1, 9}),
+			"error: bad name\n --> a.lo:1:8\n  |\n1 | Uint32 a = 1\n  |        ^\n\n",
+		},
+		{
+			"tab and help",
+			Errorf("expected a value").At(Pos{"a.lo", 2, 10}).WithHelp("plot takes an x and a y"),
+			"error: expected a value\n --> a.lo:2:10\n  |\n2 | \tplot(a, )\n  | \t        ^\n  = help: plot takes an x and a y\n\n",
+		},
+		{
+			"wide span",
+			Errorf("unknown").Between(Pos{"a.lo", 1, 1}, Pos{"a.lo", 1, 7}),
+			"error: unknown\n --> a.lo:1:1\n  |\n1 | Uint32 a = 1\n  | ^^^^^^\n\n",
+		},
+		{
+			"no source",
+			Errorf("out of registers").InFile("b.lo").WithNote("a note"),
+			"error: out of registers\n --> b.lo\n  = note: a note\n\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var out bytes.Buffer
+		r := NewRenderer(&out)
+		r.Sources["a.lo"] = source
+		r.RenderOne(tc.d)
+		if out.String() != tc.expected {
+			T.Logf("\nTestRender | %s: expected\n%q\ngot\n%q", tc.name, tc.expected, out.String())
+			T.Fail()
+		}
+	}
+}
+
+func TestRenderColor(T *testing.T) {
+	var out bytes.Buffer
+	r := NewRenderer(&out)
+	if r.Color {
+		T.Logf("\nTestRenderColor | a buffer is not a terminal but color was turned on")
+		T.Fail()
+	}
+
+	r.Color = true
+	r.RenderOne(Errorf("broken"))
+	if !bytes.Contains(out.Bytes(), []byte(red+"error"+reset)) {
+		T.Logf("\nTestRenderColor | expected a red severity. got %q", out.String())
+		T.Fail()
+	}
+}
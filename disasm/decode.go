@@ -0,0 +1,86 @@
+package disasm
+
+import "fmt"
+
+/*
+Decode turns a single opcode into its mnemonic using Cowgod's notation.
+returns false if the opcode is not a valid chip-8 instruction
+*/
+func Decode(opcode uint16) (string, bool) {
+	nnn := opcode & 0x0FFF
+	kk := opcode & 0x00FF
+	n := opcode & 0x000F
+	x := (opcode & 0x0F00) >> 8
+	y := (opcode & 0x00F0) >> 4
+
+	switch opcode & 0xF000 {
+	case 0x0000:
+		switch opcode {
+		case 0x00E0:
+			return "CLS", true
+		case 0x00EE:
+			return "RET", true
+		}
+		return fmt.Sprintf("SYS 0x%03X", nnn), true
+	case 0x1000:
+		return fmt.Sprintf("JP 0x%03X", nnn), true
+	case 0x2000:
+		return fmt.Sprintf("CALL 0x%03X", nnn), true
+	case 0x3000:
+		return fmt.Sprintf("SE V%X, 0x%02X", x, kk), true
+	case 0x4000:
+		return fmt.Sprintf("SNE V%X, 0x%02X", x, kk), true
+	case 0x5000:
+		if n == 0 {
+			return fmt.Sprintf("SE V%X, V%X", x, y), true
+		}
+	case 0x6000:
+		return fmt.Sprintf("LD V%X, 0x%02X", x, kk), true
+	case 0x7000:
+		return fmt.Sprintf("ADD V%X, 0x%02X", x, kk), true
+	case 0x8000:
+		mnemonics := map[uint16]string{
+			0x0: "LD", 0x1: "OR", 0x2: "AND", 0x3: "XOR",
+			0x4: "ADD", 0x5: "SUB", 0x6: "SHR", 0x7: "SUBN", 0xE: "SHL",
+		}
+		if mnemonic, ok := mnemonics[n]; ok {
+			return fmt.Sprintf("%s V%X, V%X", mnemonic, x, y), true
+		}
+	case 0x9000:
+		if n == 0 {
+			return fmt.Sprintf("SNE V%X, V%X", x, y), true
+		}
+	case 0xA000:
+		return fmt.Sprintf("LD I, 0x%03X", nnn), true
+	case 0xB000:
+		return fmt.Sprintf("JP V0, 0x%03X", nnn), true
+	case 0xC000:
+		return fmt.Sprintf("RND V%X, 0x%02X", x, kk), true
+	case 0xD000:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, n), true
+	case 0xE000:
+		switch kk {
+		case 0x9E:
+			return fmt.Sprintf("SKP V%X", x), true
+		case 0xA1:
+			return fmt.Sprintf("SKNP V%X", x), true
+		}
+	case 0xF000:
+		formats := map[uint16]string{
+			0x07: "LD V%X, DT",
+			0x0A: "LD V%X, K",
+			0x15: "LD DT, V%X",
+			0x18: "LD ST, V%X",
+			0x1E: "ADD I, V%X",
+			0x29: "LD F, V%X",
+			0x33: "LD B, V%X",
+			0x55: "LD [I], V%X",
+			0x65: "LD V%X, [I]",
+		}
+		if format, ok := formats[kk]; ok {
+			return fmt.Sprintf(format, x), true
+		}
+	}
+
+	return fmt.Sprintf("DW 0x%04X", opcode), false
+}
@@ -0,0 +1,206 @@
+package disasm
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/fabulousduck/smol/sourcemap"
+)
+
+/*
+ProgramStart is the address chip-8 ROMs are loaded at.
+All addresses in the output are machine addresses, not file offsets
+*/
+const ProgramStart = 0x200
+
+/*
+zeroRunThreshold is the amount of zero bytes in a row after which
+they are collapsed into a single line. Compiled smol ROMs have a
+big gap of zeroes between the code and variable space
+*/
+const zeroRunThreshold = 16
+
+/*
+Line is a single line of disassembly.
+Either a decoded instruction or a run of data bytes
+*/
+type Line struct {
+	Addr  int
+	Bytes []byte
+	Text  string
+	Data  bool
+}
+
+/*
+Disassemble decodes a ROM into lines.
+
+Bytes are only decoded as instructions when they can be reached from
+the start of the program by following the control flow. Everything else,
+like sprite data placed by SETMEM, is shown as data
+*/
+func Disassemble(rom []byte) []Line {
+	code := findCode(rom)
+	lines := []Line{}
+	data := []byte{}
+	dataStart := 0
+
+	flushData := func() {
+		if len(data) > 0 {
+			lines = append(lines, dataLines(dataStart, data)...)
+			data = []byte{}
+		}
+	}
+
+	for offset := 0; offset < len(rom); {
+		if code[offset] {
+			flushData()
+			opcode := uint16(rom[offset])<<8 | uint16(rom[offset+1])
+			text, _ := Decode(opcode)
+			lines = append(lines, Line{Addr: ProgramStart + offset, Bytes: rom[offset : offset+2], Text: text})
+			offset += 2
+			continue
+		}
+
+		if len(data) == 0 {
+			dataStart = ProgramStart + offset
+		}
+		data = append(data, rom[offset])
+		offset++
+	}
+	flushData()
+
+	return lines
+}
+
+/*
+findCode walks the control flow of the ROM starting at the program start.
+returns which offsets in the rom are the start of an instruction
+*/
+func findCode(rom []byte) []bool {
+	code := make([]bool, len(rom))
+	queue := []int{ProgramStart}
+
+	for len(queue) > 0 {
+		addr := queue[0]
+		queue = queue[1:]
+
+		offset := addr - ProgramStart
+		if offset < 0 || offset+1 >= len(rom) || code[offset] {
+			continue
+		}
+
+		opcode := uint16(rom[offset])<<8 | uint16(rom[offset+1])
+		//an empty opcode means we ran past the end of the program
+		if opcode == 0x0000 {
+			continue
+		}
+		if _, ok := Decode(opcode); !ok {
+			continue
+		}
+		code[offset] = true
+
+		nnn := int(opcode & 0x0FFF)
+		switch opcode & 0xF000 {
+		case 0x0000:
+			if opcode != 0x00EE {
+				queue = append(queue, addr+2)
+			}
+		case 0x1000:
+			queue = append(queue, nnn)
+		case 0x2000:
+			queue = append(queue, nnn, addr+2)
+		case 0x3000, 0x4000, 0x5000, 0x9000, 0xE000:
+			queue = append(queue, addr+2, addr+4)
+		case 0xB000:
+			//the target depends on V0, so we cannot follow it
+		default:
+			queue = append(queue, addr+2)
+		}
+	}
+
+	return code
+}
+
+//dataLines splits a run of data bytes into lines of at most 8 bytes. Long runs of zeroes become a single line
+func dataLines(addr int, data []byte) []Line {
+	lines := []Line{}
+	for len(data) > 0 {
+		zeroes := 0
+		for zeroes < len(data) && data[zeroes] == 0 {
+			zeroes++
+		}
+		if zeroes >= zeroRunThreshold {
+			lines = append(lines, Line{Addr: addr, Bytes: data[:zeroes], Text: fmt.Sprintf("DB 0x00 ; %d times", zeroes), Data: true})
+			addr += zeroes
+			data = data[zeroes:]
+			continue
+		}
+
+		size := 8
+		if len(data) < size {
+			size = len(data)
+		}
+		text := bytes.NewBufferString("DB ")
+		for i, b := range data[:size] {
+			if i > 0 {
+				text.WriteString(", ")
+			}
+			fmt.Fprintf(text, "0x%02X", b)
+		}
+		lines = append(lines, Line{Addr: addr, Bytes: data[:size], Text: text.String(), Data: true})
+		addr += size
+		data = data[size:]
+	}
+	return lines
+}
+
+/*
+Format renders lines as text
+
+	0x200  600A  LD V0, 0x0A
+*/
+func Format(lines []Line) string {
+	var out bytes.Buffer
+	for _, line := range lines {
+		out.WriteString(formatLine(line))
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+/*
+FormatWithMap renders lines as text and adds what the source map knows about them.
+Every function starts with its name and the first opcode of every line of source
+says where in the source it comes from
+
+	add:
+	0x204  8014  ADD V0, V1      ; example.lo:3:5
+*/
+func FormatWithMap(lines []Line, m *sourcemap.Map) string {
+	var out bytes.Buffer
+	var last sourcemap.Line
+	for _, line := range lines {
+		for _, function := range m.Functions {
+			if function.Start == line.Addr {
+				fmt.Fprintf(&out, "%s:\n", function.Name)
+			}
+		}
+
+		text := formatLine(line)
+		if source, ok := m.Lookup(line.Addr); ok && !line.Data && source != last {
+			text = fmt.Sprintf("%-32s; %s:%d:%d", text, m.File, source.Line, source.Col)
+			last = source
+		}
+		out.WriteString(text)
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+func formatLine(line Line) string {
+	hex := fmt.Sprintf("%X", line.Bytes)
+	if len(line.Bytes) > 2 {
+		hex = fmt.Sprintf("%02X..", line.Bytes[0])
+	}
+	return fmt.Sprintf("0x%03X  %-4s  %s", line.Addr, hex, line.Text)
+}
@@ -0,0 +1,69 @@
+package disasm
+
+import (
+	"testing"
+)
+
+func TestDecode(T *testing.T) {
+	opcodes := map[uint16]string{
+		0x00E0: "CLS",
+		0x00EE: "RET",
+		0x120C: "JP 0x20C",
+		0x2ABC: "CALL 0xABC",
+		0x4CFF: "SNE VC, 0xFF",
+		0x630A: "LD V3, 0x0A",
+		0x8E00: "LD VE, V0",
+		0x8034: "ADD V0, V3",
+		0x8125: "SUB V1, V2",
+		0x8127: "SUBN V1, V2",
+		0x9120: "SNE V1, V2",
+		0xAEA0: "LD I, 0xEA0",
+		0xDED1: "DRW VE, VD, 1",
+		0xF033: "LD B, V0",
+		0xF265: "LD V2, [I]",
+	}
+
+	for opcode, expected := range opcodes {
+		text, ok := Decode(opcode)
+		if !ok || text != expected {
+			T.Logf("\nTestDecode | %04X decoded as %q. expected %q", opcode, text, expected)
+			T.Fail()
+		}
+	}
+
+	if _, ok := Decode(0x5121); ok {
+		T.Logf("\nTestDecode | 5121 is not a valid opcode")
+		T.Fail()
+	}
+}
+
+func TestDataRegions(T *testing.T) {
+	rom := make([]byte, 0x30)
+	copy(rom, []byte{
+		0x60, 0x0A, // 0x200 LD V0, 0x0A
+		0xA2, 0x2C, // 0x202 LD I, 0x22C
+		0xD0, 0x01, // 0x204 DRW V0, V0, 1
+		0x12, 0x06, // 0x206 JP 0x206
+	})
+	rom[0x2C] = 0x80
+
+	lines := Disassemble(rom)
+	expected := []Line{
+		{Addr: 0x200, Text: "LD V0, 0x0A"},
+		{Addr: 0x202, Text: "LD I, 0x22C"},
+		{Addr: 0x204, Text: "DRW V0, V0, 1"},
+		{Addr: 0x206, Text: "JP 0x206"},
+		{Addr: 0x208, Text: "DB 0x00 ; 36 times", Data: true},
+		{Addr: 0x22C, Text: "DB 0x80, 0x00, 0x00, 0x00", Data: true},
+	}
+
+	if len(lines) != len(expected) {
+		T.Fatalf("\nTestDataRegions | expected %d lines. got:\n%s", len(expected), Format(lines))
+	}
+	for i := range expected {
+		if lines[i].Addr != expected[i].Addr || lines[i].Text != expected[i].Text || lines[i].Data != expected[i].Data {
+			T.Logf("\nTestDataRegions | line %d is %+v. expected %+v", i, lines[i], expected[i])
+			T.Fail()
+		}
+	}
+}
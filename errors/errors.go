@@ -3,12 +3,20 @@ package errors
 import (
 	"bytes"
 	"fmt"
+	"strings"
+
+	"github.com/fabulousduck/smol/diag"
 )
 
-//Report can be used for appending info to the error message such as line number and file
-func Report(line int, where string, message string) {
-	//TODO: make this nicer
-	fmt.Printf("\n[%s|%d] %s\n\n", where, line, message)
+/*
+newError creates an error diagnostic with a code.
+Codes are stable, tools match on them, so a code is never reused for another error.
+docs/errors.txt lists them all
+*/
+func newError(code string, format string, args ...interface{}) diag.Diagnostic {
+	d := diag.Errorf(format, args...)
+	d.Code = code
+	return d
 }
 
 //ConcatVariables is a simple formatter to create a variable error string
@@ -21,111 +29,147 @@ func ConcatVariables(vars []string, sep string) string {
 }
 
 //UnknownFunctionName is an error when a lookup on a function is done but none could be found
-func UnknownFunctionName(name string) {
-	fmt.Printf("tried to call unknown function: %s\n", name)
+func UnknownFunctionName(name string) diag.Diagnostic {
+	return newError("E0201", "tried to call unknown function: %s", name)
 }
 
 //IlligalRegisterAccess is thrown by the register table when it detects the compilers accesses a non existant register
-func IlligalRegisterAccess(register int) {
-	fmt.Printf("illigal access of register: %d", register)
+func IlligalRegisterAccess(register int) diag.Diagnostic {
+	return newError("E0900", "illigal access of register: %d", register)
 }
 
 //UnAssignedMemoryLookupError is an error for the IR to throw when it wants to find a variable by addr in the memtable that does not exist
-func UnAssignedMemoryLookupError() {
-	fmt.Printf("tried to look up register that is empty while expecting it to be full")
+func UnAssignedMemoryLookupError() diag.Diagnostic {
+	return newError("E0901", "tried to look up register that is empty while expecting it to be full")
 }
 
 //UnknownTypeError is an error for the AST generator for when it encounters a token that it does not have a name for
-func UnknownTypeError() {
-	fmt.Printf("Unknown token type found.")
+func UnknownTypeError() diag.Diagnostic {
+	return newError("E0105", "Unknown token type found")
 }
 
 //UnknownVariableTypeError is an error when a variable is declared with a type that is not known
-func UnknownVariableTypeError(variableType string) {
-	fmt.Printf("Unknown variable type: %s\n", variableType)
+func UnknownVariableTypeError(variableType string) diag.Diagnostic {
+	return newError("E0301", "Unknown variable type: %s", variableType)
 }
 
 //UnresolvableVariableValueError can be thrown when the value type of a variable cannot be determined
-func UnresolvableVariableValueError() {
-	fmt.Printf("Could not resolve type of variable")
+func UnresolvableVariableValueError() diag.Diagnostic {
+	return newError("E0302", "Could not resolve type of variable")
 }
 
 //ExpressionAbortError is used when an expression ends abruptly when another token is expected
-func ExpressionAbortError() {
-	fmt.Printf("Expected another expression token. Got none")
+func ExpressionAbortError() diag.Diagnostic {
+	return newError("E0103", "Expected another expression token. Got none")
 }
 
 //ExpectedExpressionError is used when an expression is expected but none are given
-func ExpectedExpressionError() {
-	fmt.Printf("Expected expression. got nothing instead")
+func ExpectedExpressionError() diag.Diagnostic {
+	return newError("E0102", "Expected expression. got nothing instead")
 }
 
 //InvalidOperatorError is used when the attributes of an unknown operator is requested
-func InvalidOperatorError() {
-	fmt.Printf("Given operator does not have any attributes\n")
+func InvalidOperatorError() diag.Diagnostic {
+	return newError("E0104", "Given operator does not have any attributes")
 }
 
 //LitteralFree error can be thrown when the programmer wants to free a number litteral
-func LitteralFree() {
-	fmt.Printf("Cannot release a number litteral\n")
+func LitteralFree() diag.Diagnostic {
+	return newError("E0306", "Cannot release a number litteral")
 }
 
 //UndefinedVariableError can be thrown at interpret time when a variable is not found on the local scope or higher level scopes
-func UndefinedVariableError(variableName string) {
-	//TODO: make this somewhat more informative
-	fmt.Printf("Undefined variable %s\n", variableName)
+func UndefinedVariableError(variableName string) diag.Diagnostic {
+	return newError("E0200", "Undefined variable %s", variableName)
 }
 
 //LitAssignError can be used when the script tries to assign a new value to a litteral value
-func LitAssignError() {
-	fmt.Printf("Cannot assign new value to litteral value")
+func LitAssignError() diag.Diagnostic {
+	return newError("E0304", "Cannot assign new value to litteral value")
 }
 
 //LitIncrementError can be thrown when the script wants to call INC on a litteral. We do not support this as litterals are not expressions and we dont support returns yet
-func LitIncrementError() {
-	fmt.Printf("Cannot increment a num literal\n")
+func LitIncrementError() diag.Diagnostic {
+	return newError("E0305", "Cannot increment a num literal")
 }
 
 //UndefinedFunctionReferenceError can be thrown when the script tries to reference an error that is not defined
-func UndefinedFunctionOrStringReferenceError(name string) {
-	fmt.Printf("Cannot find function or string with name: %s\n", name)
+func UndefinedFunctionOrStringReferenceError(name string) diag.Diagnostic {
+	return newError("E0202", "Cannot find function or string with name: %s", name)
 }
 
 //IncorrectFunctionParamCountError can be throw when more or less arguments are provided to a function than it asks for. We dont support argument defaulting so this is usefull
-func IncorrectFunctionParamCountError(name string, given int, expected int) {
-	fmt.Printf("function \"%s\" requires %d arguments. Got %d\n", name, expected, given)
+func IncorrectFunctionParamCountError(name string, given int, expected int) diag.Diagnostic {
+	return newError("E0203", "function \"%s\" requires %d arguments. Got %d", name, expected, given)
 }
 
 //ROMModError can be thrown when a variable modification is called on a variable that is not loaded into a register.
 //the user is most likely attempting to change rom here
-func ROMModError() {
-	fmt.Printf("Trying to modify variable that is not loaded into memory")
+func ROMModError() diag.Diagnostic {
+	return newError("E0309", "Trying to modify variable that is not loaded into memory")
 }
 
 //MathInvalidReceiverError can be thrown when the script wants to do a mathematical statement but does not have a receiver for the outcome as LHS
-func MathInvalidReceiverError() {
-	fmt.Printf("left hand side of mathematical operation must be variable")
+func MathInvalidReceiverError() diag.Diagnostic {
+	return newError("E0307", "left hand side of mathematical operation must be variable")
 }
 
 //UnknownSwitchNode is thrown when something else than EOS or CAS is found as a top level definition in a switch
-func UnknownSwitchNode() {
-	fmt.Printf("unknown definition found in switch")
+func UnknownSwitchNode() diag.Diagnostic {
+	return newError("E0107", "unknown definition found in switch")
+}
+
+//TypeMismatchError can be thrown when a value is given to a variable that does not match the declared type of the variable
+func TypeMismatchError(variableName string, expected string, got string) diag.Diagnostic {
+	return newError("E0300", "Cannot use value of type %s as %s for variable %s", got, expected, variableName)
+}
+
+//DivisionByZeroError can be thrown at interpret time when the right hand side of a division is 0
+func DivisionByZeroError() diag.Diagnostic {
+	return newError("E0308", "Division by zero")
+}
+
+//VoidFunctionValueError can be thrown when a function is used as a value. Functions do not support return values yet
+func VoidFunctionValueError(name string) diag.Diagnostic {
+	return newError("E0303", "function \"%s\" does not return a value and cannot be used in an expression", name)
+}
+
+//UnsupportedOperationError can be thrown when an operation is parsed but not supported by the current backend
+func UnsupportedOperationError(operation string) diag.Diagnostic {
+	return newError("E0400", "Unsupported operation: %s", operation)
 }
 
 //EOFError allows us to throw an error when either the lexer or the AST generator runs out of tokens / characters to parse
 //while it still expects there to be a token or character.
-func EOFError() {
-	fmt.Printf("EOF found in program execution.")
+func EOFError() diag.Diagnostic {
+	return newError("E0106", "EOF found in program execution")
+}
+
+//UnknownInstructionError is used by the bytecode generator when it is handed an IR instruction it cannot encode
+func UnknownInstructionError(name string) diag.Diagnostic {
+	return newError("E0407", "cannot generate bytecode for unknown IR instruction: %s", name)
+}
+
+//ROMLayoutError is used when the bytecode generator cannot lay out the ROM, for example when a label is missing
+func ROMLayoutError(message string) diag.Diagnostic {
+	return newError("E0406", "cannot lay out ROM: %s", message)
+}
+
+//InlineAssemblyError is used when an asm block in a smol program cannot be assembled
+func InlineAssemblyError(message string) diag.Diagnostic {
+	return newError("E0403", "inline assembly: %s", message)
 }
 
 //OutOfRegistersError is and error that indicates someone tried to assign more values than is allowed by the bytecode generator
-func OutOfRegistersError() {
-	fmt.Printf("Tried to store more variables than available registers (15) ")
+func OutOfRegistersError(available int, inUse []string) diag.Diagnostic {
+	return newError("E0401", "Tried to store more variables than available registers").
+		WithHelp(fmt.Sprintf("only %d general-purpose registers are available; variables in use: %s", available, strings.Join(inUse, ", "))).
+		WithHelp("variables that are no longer needed can be released with free")
 }
 
 //OutOfMemoryError can be thrown when the compiler has no more space to place a variable
-func OutOfMemoryError() {
-	fmt.Printf("Out of memory error")
+func OutOfMemoryError() diag.Diagnostic {
+	return newError("E0402", "Out of memory error")
 }
 
 /*
@@ -135,10 +179,55 @@ a memory address as arg one
 TODO: rename this to something more appropriate
 TODO: maybe even make a separate errors package for internal errors
 */
-func RegisterAdressModeFailure(attemptedRegisterIndex int) {
-	fmt.Printf("Invalid MOV to register [%d]. 0xF boundary exceeded", attemptedRegisterIndex)
+func RegisterAdressModeFailure(attemptedRegisterIndex int) diag.Diagnostic {
+	return newError("E0902", "Invalid MOV to register [%d]. 0xF boundary exceeded", attemptedRegisterIndex)
 }
 
 /*
 s
 */
+
+//AssemblyError is used when a chip-8 assembly file cannot be assembled
+func AssemblyError(message string) diag.Diagnostic {
+	return newError("E0404", "%s", message)
+}
+
+//IRSyntaxError is used when IR in its text form cannot be read
+func IRSyntaxError(message string) diag.Diagnostic {
+	return newError("E0405", "%s", message)
+}
+
+//UndefinedSymbolError is used by the lexer when it finds a character that is not part of smol
+func UndefinedSymbolError(symbol string) diag.Diagnostic {
+	return newError("E0100", "undefined symbol \"%s\" used", symbol)
+}
+
+//UnexpectedTokenError is used by the parser when a token is not one of the types it expected
+func UnexpectedTokenError(expected []string, got string) diag.Diagnostic {
+	return newError("E0101", "expected one of [%s]. got %s", strings.Join(expected, ", "), got)
+}
+
+//UnclosedBlockError is used by the parser when a block is not closed with end before the file ends
+func UnclosedBlockError(keyword string) diag.Diagnostic {
+	return newError("E0108", "%s block is not closed with end", keyword)
+}
+
+//ElseWithoutIfError is used by the parser when an else or elif is found that does not follow the body of an if
+func ElseWithoutIfError(keyword string) diag.Diagnostic {
+	return newError("E0109", "%s without if", keyword)
+}
+
+//FileReadError is used when the file that should be compiled or run cannot be read
+func FileReadError(reason string) diag.Diagnostic {
+	return newError("E0500", "cannot read file: %s", reason)
+}
+
+//ROMWriteError is used when the ROM cannot be written to the file it should go to
+func ROMWriteError(path string, reason string) diag.Diagnostic {
+	return newError("E0501", "cannot write the ROM to %s: %s", path, reason)
+}
+
+//CompilerCrashError is used when a stage of the compiler panics on a program instead of reporting what is wrong with it
+func CompilerCrashError(reason interface{}) diag.Diagnostic {
+	return newError("E0903", "the compiler crashed: %v", reason)
+}
@@ -1,50 +1,21 @@
 package file
 
 import (
-	"fmt"
-	"log"
 	"os"
 )
 
 /*
 Create is a simple helper function to create a file at path
-Also checks if the file at path already exists
+A file that already exists at path is emptied
 */
-func Create(path string) *os.File {
-	// create file if not exists
-	var file, err = os.Create("ROM")
-
-	if isError(err) {
-		os.Exit(65)
-	}
-	return file
-}
-
-func isError(err error) bool {
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-
-	return (err != nil)
+func Create(path string) (*os.File, error) {
+	return os.Create(path)
 }
 
 /*
-WriteBytes writes bytes to addr in the given file
+Write writes a complete ROM image to the given file
 */
-func WriteBytes(file *os.File, bytes []byte, particularOffset bool, addr int64) {
-	fmt.Printf("%04X\n", addr)
-	var jmpFileLoc int64
-	if particularOffset {
-		originalOffset, _ := file.Seek(0, 1)
-		jmpFileLoc = originalOffset
-		file.Seek(addr, 0)
-	}
-	bytesWritten, err := file.Write(bytes)
-	if err != nil {
-		log.Fatal(err)
-	}
-	log.Printf("Wrote %d bytes.\n", bytesWritten)
-	if particularOffset {
-		file.Seek(jmpFileLoc, 0)
-	}
+func Write(file *os.File, bytes []byte) error {
+	_, err := file.Write(bytes)
+	return err
 }
@@ -0,0 +1,252 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/lexer"
+)
+
+//Indent is what a statement is indented with for every block it is in
+const Indent = "    "
+
+/*
+Source formats a smol program.
+
+	def add(a,b) :
+	print(a);
+	        end
+
+becomes
+
+	def add(a, b):
+	    print(a)
+	end
+
+The body of every block that ends with end is indented one level deeper than the line that opened it,
+else and elif line up with the if they belong to.
+Operators are surrounded by a single space, commas are followed by one and
+semicolons are only kept where set needs them. Comments stay on the line they were on,
+at most one empty line is kept between statements and the file ends with a single newline.
+
+Programs that do not parse are not formatted, the diagnostics tell why
+*/
+func Source(filename string, src string) (string, diag.List) {
+	l := lexer.NewLexer(filename, src)
+	l.KeepComments = true
+	l.Lex()
+
+	code := []lexer.Token{}
+	for _, token := range l.Tokens {
+		if token.Type != "comment" {
+			code = append(code, token)
+		}
+	}
+	p := ast.NewParser(filename, code)
+	p.Diagnostics = l.Diagnostics
+	p.Parse("")
+	if l.Diagnostics.HasErrors() {
+		return "", *l.Diagnostics
+	}
+
+	f := new(formatter)
+	for _, line := range splitLines(l.Tokens) {
+		f.line(line)
+	}
+	return f.String(), *l.Diagnostics
+}
+
+//formatter writes formatted lines and keeps track of how deep in blocks they are
+type formatter struct {
+	strings.Builder
+	depth    int
+	lastLine int
+	blank    bool
+}
+
+//splitLines groups tokens by the line they start on
+func splitLines(tokens []lexer.Token) [][]lexer.Token {
+	var lines [][]lexer.Token
+	for i, token := range tokens {
+		if i == 0 || token.Line != tokens[i-1].Line {
+			lines = append(lines, nil)
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], token)
+	}
+	return lines
+}
+
+//line writes the tokens of a single line of source
+func (f *formatter) line(tokens []lexer.Token) {
+	//empty lines between statements are squashed into one, the ones at the start of the file are dropped
+	if f.Len() > 0 && tokens[0].Line > f.lastLine+1 {
+		f.blank = true
+	}
+	f.lastLine = tokens[0].Line
+
+	if closesBlock(tokens) && f.depth > 0 {
+		f.depth--
+	}
+	tokens = dropSemicolon(tokens)
+
+	var b strings.Builder
+	var body *lexer.Token
+	for i, token := range tokens {
+		if token.Type == "asm_body" {
+			//anything after asm: on its own line is kept there
+			if rest := strings.TrimSpace(strings.SplitN(token.Value, "\n", 2)[0]); rest != "" {
+				b.WriteString(" " + rest)
+			}
+			body = &tokens[i]
+			continue
+		}
+		if i > 0 && spaced(tokens, i) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(text(token))
+	}
+	f.write(b.String())
+
+	if opensBlock(tokens) {
+		f.depth++
+	}
+	if body != nil {
+		f.asmBody(body)
+	}
+}
+
+//write writes a line at the current depth
+func (f *formatter) write(line string) {
+	if f.blank {
+		f.WriteByte('\n')
+		f.blank = false
+	}
+	f.WriteString(strings.Repeat(Indent, f.depth))
+	f.WriteString(line)
+	f.WriteByte('\n')
+}
+
+/*
+asmBody writes the lines of an asm block. assembly is not smol
+so only the indentation of the block as a whole is changed,
+the lines keep their indentation relative to each other
+*/
+func (f *formatter) asmBody(body *lexer.Token) {
+	lines := strings.Split(body.Value, "\n")
+	//the first line is what follows the colon of asm:
+	lines = lines[1:]
+	f.lastLine = body.Line + len(lines)
+
+	margin := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			margin, first = indent, false
+		}
+		for !strings.HasPrefix(indent, margin) {
+			margin = margin[:len(margin)-1]
+		}
+	}
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			f.WriteByte('\n')
+			continue
+		}
+		f.write(strings.TrimPrefix(line, margin))
+	}
+}
+
+//opensBlock reports whether a line starts a block, which it does when its last token is a colon
+func opensBlock(tokens []lexer.Token) bool {
+	last := code(tokens)
+	return last >= 0 && (tokens[last].Type == "double_dot" || tokens[last].Type == "asm_body")
+}
+
+//closesBlock reports whether a line ends a block. else and elif end the block above them and open one of their own
+func closesBlock(tokens []lexer.Token) bool {
+	switch tokens[0].Type {
+	case "close_block", "else_statement", "elif_statement":
+		return true
+	}
+	return false
+}
+
+//code returns the index of the last token on a line that is not a comment
+func code(tokens []lexer.Token) int {
+	last := len(tokens) - 1
+	for last >= 0 && tokens[last].Type == "comment" {
+		last--
+	}
+	return last
+}
+
+//dropSemicolon removes the semicolon at the end of a line unless it ends a set statement
+func dropSemicolon(tokens []lexer.Token) []lexer.Token {
+	last := code(tokens)
+	if last <= 0 || tokens[last].Type != "semicolon" || tokens[0].Type == "set_variable" {
+		return tokens
+	}
+	return append(tokens[:last:last], tokens[last+1:]...)
+}
+
+//text gives the source of a token
+func text(token lexer.Token) string {
+	if token.Type == "string_litteral" {
+		return "\"" + token.Value + "\""
+	}
+	return token.Value
+}
+
+//operators are written with a space on either side
+var operators = map[string]bool{
+	"plus":                      true,
+	"dash":                      true,
+	"star":                      true,
+	"division":                  true,
+	"exponent":                  true,
+	"less_than":                 true,
+	"greater_than":              true,
+	"equals":                    true,
+	"comparison":                true,
+	"not_equal":                 true,
+	"less_than_or_equal":        true,
+	"greater_than_or_equal":     true,
+	"direct_variable_operation": true,
+}
+
+//spaced reports whether the token at i is written with a space before it
+func spaced(tokens []lexer.Token, i int) bool {
+	prev, token := tokens[i-1], tokens[i]
+	switch {
+	case token.Type == "comment":
+		return true
+	case token.Value == "++" || token.Value == "--":
+		return false
+	case prev.Type == "left_parenthesis" || prev.Type == "left_bracket":
+		return false
+	case token.Type == "right_parenthesis" || token.Type == "right_bracket" || token.Type == "comma" ||
+		token.Type == "double_dot" || token.Type == "semicolon":
+		return false
+	case prev.Type == "dash" && unary(tokens, i-1):
+		return false
+	case token.Type == "left_parenthesis" || token.Type == "left_bracket":
+		return operators[prev.Type] || prev.Type == "comma"
+	}
+	return true
+}
+
+//unary reports whether the dash at i negates what follows it instead of subtracting
+func unary(tokens []lexer.Token, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := tokens[i-1].Type
+	return operators[prev] || prev == "left_parenthesis" || prev == "comma" || prev == "double_dot"
+}
@@ -0,0 +1,53 @@
+package format
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSource(T *testing.T) {
+	source := "\n\n#adds things\ndef add(a,b) :\nprint(a);   #the first one\n        if(a<b):\n  print(\"a < b\")\n end\n\n\n\n   end\nUint32 c = 10;\nc++;\nset c 2;\nasm:\n        LD V0, c\n    loop:\n        JP loop\nend\nfnb(add(1,2) + -1, 2*2)"
+	expected := "#adds things\ndef add(a, b):\n    print(a) #the first one\n    if(a < b):\n        print(\"a < b\")\n    end\n\nend\nUint32 c = 10\nc++\nset c 2;\nasm:\n        LD V0, c\n    loop:\n        JP loop\nend\nfnb(add(1, 2) + -1, 2 * 2)\n"
+
+	formatted, diagnostics := Source("test.lo", source)
+	if len(diagnostics) != 0 || formatted != expected {
+		T.Logf("\nTestSource | expected\n%s\ngot\n%s\n%v", expected, formatted, diagnostics)
+		T.Fail()
+	}
+}
+
+func TestSourceElse(T *testing.T) {
+	source := "if(a<=b):\nprint(a)\n    elif(a!=b):\nprint(b)\n  else :\n        print(a)\nend\n"
+	expected := "if(a <= b):\n    print(a)\nelif(a != b):\n    print(b)\nelse:\n    print(a)\nend\n"
+
+	formatted, diagnostics := Source("test.lo", source)
+	if len(diagnostics) != 0 || formatted != expected {
+		T.Logf("\nTestSourceElse | expected\n%s\ngot\n%s\n%v", expected, formatted, diagnostics)
+		T.Fail()
+	}
+}
+
+func TestSourceIsStable(T *testing.T) {
+	files, _ := filepath.Glob("../examples/*.lo")
+	for _, file := range files {
+		source, _ := ioutil.ReadFile(file)
+		once, diagnostics := Source(file, string(source))
+		if diagnostics.HasErrors() {
+			continue
+		}
+		twice, _ := Source(file, once)
+		if once != twice {
+			T.Logf("\nTestSourceIsStable | formatting %s twice gave\n%s\ninstead of\n%s", file, twice, once)
+			T.Fail()
+		}
+	}
+}
+
+func TestSourceSyntaxError(T *testing.T) {
+	formatted, diagnostics := Source("test.lo", "Uint32 a = 1\na = 2;\n")
+	if formatted != "" || !diagnostics.HasErrors() || diagnostics[0].Code != "E0101" {
+		T.Logf("\nTestSourceSyntaxError | expected a program that does not parse to be left alone. got %q %v", formatted, diagnostics)
+		T.Fail()
+	}
+}
@@ -0,0 +1,161 @@
+package interpreter
+
+import (
+	"strconv"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/errors"
+	"github.com/fabulousduck/smol/lexer"
+)
+
+/*
+eval resolves a value node (litterals, variable references and expressions)
+to a runtime value
+*/
+func (i *Interpreter) eval(node ast.Node, scope *Scope) Value {
+	switch node.GetNodeName() {
+	case "numLit":
+		return i.numberFromString(node.(*ast.NumLit).Value)
+	case "stringLit":
+		return newString(node.(*ast.StringLit).Value)
+	case "boolLit":
+		return newBool(node.(*ast.BoolLit).Value == "True")
+	case "statVar":
+		return i.resolveName(node.(*ast.StatVar).Value, node, scope)
+	case "expression":
+		switch expression := node.(type) {
+		case ast.Expression:
+			return i.evalExpression(expression, scope)
+		case *ast.Expression:
+			return i.evalExpression(*expression, scope)
+		}
+	}
+
+	i.fail(errors.UnresolvableVariableValueError())
+	return Value{}
+}
+
+//evalCondition checks if a condition holds. a value on its own holds when it is truthy
+func (i *Interpreter) evalCondition(condition *ast.Condition, scope *Scope) bool {
+	lhs := i.eval(condition.Left, scope)
+	if condition.Operator == "" {
+		return lhs.Truthy()
+	}
+
+	rhs := i.eval(condition.Right, scope)
+	switch condition.Operator {
+	case "==":
+		return lhs.Equals(rhs)
+	case "!=":
+		return !lhs.Equals(rhs)
+	}
+	if !lhs.IsNumeric() || !rhs.IsNumeric() {
+		i.fail(errors.UnsupportedOperationError(condition.Operator))
+	}
+	switch condition.Operator {
+	case "<":
+		return lhs.Num < rhs.Num
+	case "<=":
+		return lhs.Num <= rhs.Num
+	case ">=":
+		return lhs.Num >= rhs.Num
+	}
+	return lhs.Num > rhs.Num
+}
+
+func (i *Interpreter) numberFromString(value string) Value {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		i.fail(errors.UnresolvableVariableValueError())
+	}
+	return newNumber(n)
+}
+
+/*
+resolveName looks up a variable by name. Functions cannot be used as values since they do not return anything.
+node is where the name is used, errors point at the statement being run when it is nil
+*/
+func (i *Interpreter) resolveName(name string, node ast.Node, scope *Scope) Value {
+	if value, _ := scope.Lookup(name); value != nil {
+		return *value
+	}
+	if scope.LookupFunction(name) != nil {
+		i.failAt(errors.VoidFunctionValueError(name), node)
+	}
+	i.failAt(errors.UndefinedVariableError(name), node)
+	return Value{}
+}
+
+/*
+evalExpression evaluates an expression that is in RPN form
+using a simple value stack
+*/
+func (i *Interpreter) evalExpression(expression ast.Expression, scope *Scope) Value {
+	stack := []Value{}
+
+	for _, token := range expression.Tokens {
+		switch token.Type {
+		case "integer":
+			stack = append(stack, i.numberFromString(token.Value))
+		case "string_litteral":
+			stack = append(stack, newString(token.Value))
+		case "boolean_keyword":
+			stack = append(stack, newBool(token.Value == "True"))
+		case "character", "string":
+			stack = append(stack, i.resolveName(token.Value, nil, scope))
+		case "plus", "dash", "star", "division", "exponent", "less_than", "greater_than", "comparison":
+			if len(stack) < 2 {
+				i.fail(errors.ExpressionAbortError())
+			}
+			lhs, rhs := stack[len(stack)-2], stack[len(stack)-1]
+			stack = append(stack[:len(stack)-2], i.applyOperator(token, lhs, rhs))
+		}
+	}
+
+	if len(stack) != 1 {
+		i.fail(errors.ExpectedExpressionError())
+	}
+
+	return stack[0]
+}
+
+func (i *Interpreter) applyOperator(operator lexer.Token, lhs Value, rhs Value) Value {
+	if operator.Type == "comparison" {
+		return newBool(lhs.Equals(rhs))
+	}
+
+	if operator.Type == "plus" && lhs.Type == "String" && rhs.Type == "String" {
+		return newString(lhs.Str + rhs.Str)
+	}
+
+	if !lhs.IsNumeric() || !rhs.IsNumeric() {
+		i.fail(errors.UnsupportedOperationError(operator.Value))
+	}
+
+	switch operator.Type {
+	case "plus":
+		return newNumber(lhs.Num + rhs.Num)
+	case "dash":
+		return newNumber(lhs.Num - rhs.Num)
+	case "star":
+		return newNumber(lhs.Num * rhs.Num)
+	case "division":
+		if rhs.Num == 0 {
+			i.fail(errors.DivisionByZeroError())
+		}
+		return newNumber(lhs.Num / rhs.Num)
+	case "exponent":
+		result := 1
+		for n := 0; n < rhs.Num; n++ {
+			result *= lhs.Num
+		}
+		return newNumber(result)
+	case "less_than":
+		return newBool(lhs.Num < rhs.Num)
+	case "greater_than":
+		return newBool(lhs.Num > rhs.Num)
+	}
+
+	i.fail(errors.InvalidOperatorError())
+	return Value{}
+}
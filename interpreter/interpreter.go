@@ -0,0 +1,305 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
+)
+
+/*
+Interpreter walks an AST and executes it directly.
+This allows for checking the logic of a program without building a ROM.
+
+Runtime errors are added to Diagnostics and rendered to Out,
+Source is the program being run so they can show the line they are on
+*/
+type Interpreter struct {
+	Filename    string
+	Source      string
+	Out         io.Writer
+	Global      *Scope
+	Diagnostics *diag.List
+	HadError    bool
+	//current is the statement being run, errors point at it
+	current ast.Node
+}
+
+//runtimeError is used to unwind the interpreter once an error has been reported
+type runtimeError struct{}
+
+//NewInterpreter creates a new interpreter with an empty global scope that prints to stdout
+func NewInterpreter(filename string) *Interpreter {
+	i := new(Interpreter)
+	i.Filename = filename
+	i.Out = os.Stdout
+	i.Global = NewScope(nil)
+	i.Diagnostics = new(diag.List)
+	return i
+}
+
+/*
+Run executes the given nodes in the global scope.
+The global scope is kept between calls so successive calls
+can use what earlier calls declared
+*/
+func (i *Interpreter) Run(nodes []ast.Node) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(runtimeError); !ok {
+				panic(r)
+			}
+		}
+	}()
+
+	i.execBlock(nodes, i.Global)
+}
+
+//fail reports d at the statement being run and stops execution of the current Run call
+func (i *Interpreter) fail(d diag.Diagnostic) {
+	i.failAt(d, nil)
+}
+
+/*
+failAt reports d at node, like the name of a variable that does not exist,
+and stops execution of the current Run call. a nil node is the statement being run
+*/
+func (i *Interpreter) failAt(d diag.Diagnostic, node ast.Node) {
+	if node == nil {
+		node = i.current
+	}
+	if node != nil {
+		if span := node.GetSpan(); span.Start.Line != 0 {
+			d = d.Between(span.Start, span.End)
+		}
+	}
+	d = d.InFile(i.Filename)
+	i.Diagnostics.Add(d)
+
+	renderer := diag.NewRenderer(i.Out)
+	renderer.Sources[i.Filename] = i.Source
+	renderer.RenderOne(d)
+
+	i.HadError = true
+	panic(runtimeError{})
+}
+
+/*
+execBlock executes a list of nodes in the given scope.
+Functions are hoisted so they can be called before their definition
+*/
+func (i *Interpreter) execBlock(nodes []ast.Node, scope *Scope) {
+	for _, node := range nodes {
+		if node.GetNodeName() == "function" {
+			fn := node.(*ast.Function)
+			scope.Functions[fn.Name] = &Closure{Function: fn, Scope: scope}
+		}
+	}
+
+	for _, node := range nodes {
+		i.exec(node, scope)
+	}
+}
+
+func (i *Interpreter) exec(node ast.Node, scope *Scope) {
+	outer := i.current
+	i.current = node
+	defer func() { i.current = outer }()
+
+	switch node.GetNodeName() {
+	case "variable":
+		i.execVariable(node.(*ast.Variable), scope)
+	case "directOperation":
+		i.execDirectOperation(node.(*ast.DirectOperation), scope)
+	case "statement":
+		statement := node.(*ast.Statement)
+		if statement.LHS == "INC" {
+			i.execDirectOperation(&ast.DirectOperation{Variable: statement.RHS, Operation: "++"}, scope)
+		}
+	case "setStatement":
+		i.execSetStatement(node.(*ast.SetStatement), scope)
+	case "freeStatement":
+		i.execFreeStatement(node.(*ast.FreeStatement), scope)
+	case "printCall":
+		printCall := node.(*ast.PrintCall)
+		fmt.Fprintln(i.Out, i.eval(printCall.Printable, scope).String())
+	case "IfStatement":
+		ifStatement := node.(*ast.IfStatement)
+		if i.evalCondition(ifStatement.Condition, scope) {
+			i.execBlock(ifStatement.Body, NewScope(scope))
+		} else if ifStatement.Else != nil {
+			i.execBlock(ifStatement.Else, NewScope(scope))
+		}
+	case "eqBlock":
+		block := node.(*ast.EqBlock)
+		i.execComparisonBlock(block.Condition(), block.Body, scope)
+	case "neqBlock":
+		block := node.(*ast.NeqBlock)
+		i.execComparisonBlock(block.Condition(), block.Body, scope)
+	case "gtBlock":
+		block := node.(*ast.GtBlock)
+		i.execComparisonBlock(block.Condition(), block.Body, scope)
+	case "ltBlock":
+		block := node.(*ast.LtBlock)
+		i.execComparisonBlock(block.Condition(), block.Body, scope)
+	case "whileLoop":
+		loop := node.(*ast.WhileLoop)
+		for i.evalCondition(loop.Condition, scope) {
+			i.execBlock(loop.Body, NewScope(scope))
+		}
+	case "switchStatement":
+		i.execSwitchStatement(node.(*ast.SwitchStatement), scope)
+	case "functionCall":
+		i.execFunctionCall(node.(*ast.FunctionCall), scope)
+	case "function":
+		//already hoisted by execBlock
+	case "plotStatement":
+		//the interpreter has no display to draw on
+	case "asmBlock":
+		//there is no chip-8 machine to run the assembly on
+		i.fail(errors.UnsupportedOperationError("inline assembly"))
+	}
+}
+
+//execComparisonBlock runs the body of an eq, neq, gt or lt block when its comparison holds
+func (i *Interpreter) execComparisonBlock(condition *ast.Condition, body []ast.Node, scope *Scope) {
+	if i.evalCondition(condition, scope) {
+		i.execBlock(body, NewScope(scope))
+	}
+}
+
+func (i *Interpreter) execVariable(variable *ast.Variable, scope *Scope) {
+	var value Value
+	if variable.Value != nil {
+		value = i.eval(variable.Value, scope)
+	} else {
+		value = i.eval(variable.ValueExpression, scope)
+	}
+
+	i.checkType(variable.Name, variable.Type, value)
+	value.Type = variable.Type
+	scope.Declare(variable.Name, value)
+}
+
+/*
+checkType makes sure a value can be stored in a variable of type variableType
+*/
+func (i *Interpreter) checkType(name string, variableType string, value Value) {
+	switch variableType {
+	case "String", "Bool":
+		if value.Type != variableType {
+			i.fail(errors.TypeMismatchError(name, variableType, value.Type))
+		}
+	case "Uint16", "Uint32", "Uint64", "Char":
+		if !value.IsNumeric() {
+			i.fail(errors.TypeMismatchError(name, variableType, value.Type))
+		}
+	default:
+		i.fail(errors.UnknownVariableTypeError(variableType))
+	}
+}
+
+func (i *Interpreter) lookupVariable(node ast.Node, scope *Scope) *Value {
+	if !ast.NodeIsVariable(node) {
+		i.failAt(errors.LitAssignError(), node)
+	}
+	name := node.(*ast.StatVar).Value
+	value, _ := scope.Lookup(name)
+	if value == nil {
+		i.failAt(errors.UndefinedVariableError(name), node)
+	}
+	return value
+}
+
+func (i *Interpreter) execDirectOperation(do *ast.DirectOperation, scope *Scope) {
+	if !ast.NodeIsVariable(do.Variable) {
+		i.fail(errors.LitIncrementError())
+	}
+	value := i.lookupVariable(do.Variable, scope)
+	if !value.IsNumeric() {
+		i.fail(errors.TypeMismatchError(do.Variable.(*ast.StatVar).Value, "Uint32", value.Type))
+	}
+
+	switch do.Operation {
+	case "++":
+		value.Num++
+	case "--":
+		value.Num--
+	default:
+		i.fail(errors.UnsupportedOperationError(do.Operation))
+	}
+}
+
+func (i *Interpreter) execSetStatement(ss *ast.SetStatement, scope *Scope) {
+	value := i.lookupVariable(ss.MHS, scope)
+	newValue := i.eval(ss.RHS, scope)
+	i.checkType(ss.MHS.(*ast.StatVar).Value, value.Type, newValue)
+	newValue.Type = value.Type
+	*value = newValue
+}
+
+func (i *Interpreter) execFreeStatement(fs *ast.FreeStatement, scope *Scope) {
+	if !ast.NodeIsVariable(fs.Variable) {
+		i.failAt(errors.LitteralFree(), fs.Variable)
+	}
+	name := fs.Variable.(*ast.StatVar).Value
+	_, definingScope := scope.Lookup(name)
+	if definingScope == nil {
+		i.failAt(errors.UndefinedVariableError(name), fs.Variable)
+	}
+	delete(definingScope.Variables, name)
+}
+
+/*
+execSwitchStatement runs the body of the first case that matches.
+If none match, the body of the default case is run if there is one
+*/
+func (i *Interpreter) execSwitchStatement(st *ast.SwitchStatement, scope *Scope) {
+	matchValue := i.eval(st.MatchValue, scope)
+	var eos *ast.Eos
+
+	for _, node := range st.Cases {
+		switch node.GetNodeName() {
+		case "switchCase":
+			switchCase := node.(*ast.SwitchCase)
+			if i.eval(switchCase.MatchValue, scope).Equals(matchValue) {
+				i.execBlock(switchCase.Body, NewScope(scope))
+				return
+			}
+		case "end_of_switch":
+			eos = node.(*ast.Eos)
+		default:
+			i.fail(errors.UnknownSwitchNode())
+		}
+	}
+
+	if eos != nil {
+		i.execBlock(eos.Body, NewScope(scope))
+	}
+}
+
+/*
+execFunctionCall evaluates the arguments in the scope of the caller
+and runs the body of the function in a new scope on top of the scope
+the function was defined in
+*/
+func (i *Interpreter) execFunctionCall(fc *ast.FunctionCall, scope *Scope) {
+	closure := scope.LookupFunction(fc.Name)
+	if closure == nil {
+		i.fail(errors.UnknownFunctionName(fc.Name))
+	}
+
+	if len(fc.Args) != len(closure.Function.Params) {
+		i.fail(errors.IncorrectFunctionParamCountError(fc.Name, len(fc.Args), len(closure.Function.Params)))
+	}
+
+	functionScope := NewScope(closure.Scope)
+	for index, param := range closure.Function.Params {
+		functionScope.Declare(param, i.eval(fc.Args[index], scope))
+	}
+
+	i.execBlock(closure.Function.Body, functionScope)
+}
@@ -0,0 +1,102 @@
+package interpreter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/lexer"
+)
+
+func run(program string) (string, bool) {
+	l := lexer.NewLexer("TESTING", program)
+	l.Lex()
+	p := ast.NewParser("TESTING", l.Tokens)
+	nodes, _ := p.Parse("")
+
+	var out bytes.Buffer
+	i := NewInterpreter("TESTING")
+	i.Out = &out
+	i.Run(nodes)
+	return out.String(), i.HadError
+}
+
+func TestPrograms(T *testing.T) {
+	programs := []struct {
+		name, program, expected string
+	}{
+		{"print", "Uint32 a = 10\nprint(a)\nprint(10)\n", "10\n10\n"},
+		{"directOperation", "Uint32 a = 10\na++\nprint(a)\na--\na--\nprint(a)\n", "11\n9\n"},
+		{"expression", "Uint32 a = 2\nUint32 b = a * 3 + 1\nprint(b)\n", "7\n"},
+		{"set", "Uint32 a = 1\nUint32 b = 5\nset a b;\nprint(a)\n", "5\n"},
+		{"if", "Uint32 a = 0\nUint32 b = 10\nif(a < b):\n    print(a)\nend\nif(b < a):\n    print(b)\nend\n", "0\n"},
+		{"else", "Uint32 a = 5\nif(a <= 4):\n    print(1)\nelif(a != 5):\n    print(2)\nelse:\n    print(3)\nend\nif(a >= 5):\n    print(4)\nelse:\n    print(5)\nend\n", "3\n4\n"},
+		{"comparisonBlocks", "Uint32 a = 10\neq(a, 10):\n    print(1)\nend\nneq(a, 10):\n    print(2)\nend\ngt(a, 9):\n    print(3)\nend\nlt(a, 11):\n    print(4)\nend\n", "1\n3\n4\n"},
+		{"while", "Uint32 a = 0\nUint32 b = 3\nwhile(a < b):\n    print(a)\n    a++\nend\n", "0\n1\n2\n"},
+		{"whileNot", "Uint32 a = 0\nUint32 b = 2\nwhileNot(a, b):\n    Uint32 c = 0\n    while(c < 2):\n        c++\n        print(c)\n    end\n    a++\nend\n", "1\n2\n1\n2\n"},
+		{"switch", "Uint32 b = 20\nswitch(b):\n    case 10:\n        print(10)\n    end\n    case 20:\n        print(20)\n    end\n    default:\n        print(30)\n    end\nend\n", "20\n"},
+		{"switchDefault", "Uint32 b = 40\nswitch(b):\n    case 10:\n        print(10)\n    end\n    default:\n        print(30)\n    end\nend\nprint(b)\n", "30\n40\n"},
+		{"function", "fn(1, 2)\ndef fn(a, b):\n    print(a)\n    print(b)\nend\n", "1\n2\n"},
+		{"types", "String s = \"hello world!\"\nBool b = True\nprint(s)\nprint(b)\n", "hello world!\nTrue\n"},
+	}
+
+	for _, tc := range programs {
+		output, hadError := run(tc.program)
+		if hadError || output != tc.expected {
+			T.Logf("\nTestPrograms | %s produced %q (error: %t). expected %q", tc.name, output, hadError, tc.expected)
+			T.Fail()
+		}
+	}
+}
+
+func TestLexicalScope(T *testing.T) {
+	program := "Uint32 a = 1\ndef fn(b):\n    Uint32 c = b\n    print(a)\nend\nfn(2)\nprint(c)\n"
+	output, hadError := run(program)
+	if !strings.HasPrefix(output, "1\nerror[E0200]: Undefined variable c\n") || !hadError {
+		T.Logf("\nTestLexicalScope | function locals leaked into the global scope. got %q (error: %t)", output, hadError)
+		T.Fail()
+	}
+}
+
+func TestRuntimeErrors(T *testing.T) {
+	programs := []string{
+		"print(a)\n",
+		"Uint32 a = 10;\nfree a\nprint(a)\n",
+		"def fn(a):\n    print(a)\nend\nfn(1, 2)\n",
+		"Bool a = 10\n",
+		"Uint32 a = 1 / 0\n",
+	}
+
+	for _, program := range programs {
+		if _, hadError := run(program); !hadError {
+			T.Logf("\nTestRuntimeErrors | expected an error for %q", program)
+			T.Fail()
+		}
+	}
+}
+
+func TestRuntimeErrorDiagnostics(T *testing.T) {
+	program := "Uint32 a = 1\nprint(b)\n"
+	l := lexer.NewLexer("TESTING", program)
+	l.Lex()
+	p := ast.NewParser("TESTING", l.Tokens)
+	nodes, _ := p.Parse("")
+
+	var out bytes.Buffer
+	i := NewInterpreter("TESTING")
+	i.Out = &out
+	i.Source = program
+	i.Run(nodes)
+
+	diagnostics := *i.Diagnostics
+	if len(diagnostics) != 1 || diagnostics[0].Code != "E0200" || diagnostics[0].Pos.File != "TESTING" || diagnostics[0].Pos.Line != 2 || diagnostics[0].Pos.Col != 7 {
+		T.Logf("\nTestRuntimeErrorDiagnostics | expected undefined variable b at TESTING:2:7. got %v", diagnostics)
+		T.Fail()
+	}
+	expected := "error[E0200]: Undefined variable b\n --> TESTING:2:7\n  |\n2 | print(b)\n  |       ^\n\n"
+	if out.String() != expected {
+		T.Logf("\nTestRuntimeErrorDiagnostics | expected the error to be rendered to Out as\n%s\ngot\n%s", expected, out.String())
+		T.Fail()
+	}
+}
@@ -0,0 +1,62 @@
+package interpreter
+
+import "github.com/fabulousduck/smol/ast"
+
+/*
+Scope is a single lexical scope.
+Every block (function body, if body, switch case) gets its own scope
+that points back to the scope it was created in
+*/
+type Scope struct {
+	Parent    *Scope
+	Variables map[string]*Value
+	Functions map[string]*Closure
+}
+
+/*
+Closure is a function definition together with the scope it was defined in.
+Calling it creates a new scope on top of that definition scope
+*/
+type Closure struct {
+	Function *ast.Function
+	Scope    *Scope
+}
+
+//NewScope creates a new empty scope on top of parent
+func NewScope(parent *Scope) *Scope {
+	s := new(Scope)
+	s.Parent = parent
+	s.Variables = make(map[string]*Value)
+	s.Functions = make(map[string]*Closure)
+	return s
+}
+
+/*
+Lookup finds a variable by walking up the scope chain.
+Returns the value and the scope it was found in.
+Returns nil, nil if the variable is not defined
+*/
+func (s *Scope) Lookup(name string) (*Value, *Scope) {
+	for current := s; current != nil; current = current.Parent {
+		if val, ok := current.Variables[name]; ok {
+			return val, current
+		}
+	}
+	return nil, nil
+}
+
+//LookupFunction finds a function by walking up the scope chain
+func (s *Scope) LookupFunction(name string) *Closure {
+	for current := s; current != nil; current = current.Parent {
+		if fn, ok := current.Functions[name]; ok {
+			return fn
+		}
+	}
+	return nil
+}
+
+//Declare puts a variable in this scope, shadowing any variable with the same name in parent scopes
+func (s *Scope) Declare(name string, value Value) {
+	v := value
+	s.Variables[name] = &v
+}
@@ -0,0 +1,65 @@
+package interpreter
+
+import "strconv"
+
+/*
+Value is a runtime value.
+Type is the smol type name (Uint32, Uint64, Bool, String).
+Numbers and booleans live in Num, with booleans being 0 or 1 just like
+they are in the IR. Strings live in Str
+*/
+type Value struct {
+	Type string
+	Num  int
+	Str  string
+}
+
+func newNumber(n int) Value {
+	return Value{Type: "Uint32", Num: n}
+}
+
+func newBool(b bool) Value {
+	if b {
+		return Value{Type: "Bool", Num: 1}
+	}
+	return Value{Type: "Bool", Num: 0}
+}
+
+func newString(s string) Value {
+	return Value{Type: "String", Str: s}
+}
+
+//IsNumeric checks if the value can be used in arithmetic
+func (v Value) IsNumeric() bool {
+	return v.Type == "Uint16" || v.Type == "Uint32" || v.Type == "Uint64" || v.Type == "Char"
+}
+
+//Truthy is used by conditionals. Strings are truthy when they are not empty
+func (v Value) Truthy() bool {
+	if v.Type == "String" {
+		return v.Str != ""
+	}
+	return v.Num != 0
+}
+
+//Equals compares two values by type kind and contents
+func (v Value) Equals(other Value) bool {
+	if v.Type == "String" || other.Type == "String" {
+		return v.Type == other.Type && v.Str == other.Str
+	}
+	return v.Num == other.Num
+}
+
+func (v Value) String() string {
+	switch v.Type {
+	case "String":
+		return v.Str
+	case "Bool":
+		if v.Num != 0 {
+			return "True"
+		}
+		return "False"
+	default:
+		return strconv.Itoa(v.Num)
+	}
+}
@@ -1,5 +1,7 @@
 package ir
 
+import "fmt"
+
 /*
 ADD instruction
 
@@ -23,6 +25,39 @@ func (a ADD) usesVariableSpace() bool {
 	return false
 }
 
+func (a ADD) String() string {
+	return fmt.Sprintf("ADD V%X %d", a.Register, a.Value)
+}
+
 func (g *Generator) newAddInstruction(R1 int, value int) ADD {
 	return ADD{R1, value}
 }
+
+/*
+ADDRR instruction
+
+opcode: 8XY4
+X: register to add onto
+Y: register holding the value to add onto X
+
+VF is set to 1 when the result does not fit in X
+*/
+type ADDRR struct {
+	Register, AmountRegister int
+}
+
+func (a ADDRR) GetInstructionName() string {
+	return "ADDRR"
+}
+
+func (a ADDRR) Opcodeable() bool {
+	return true
+}
+
+func (a ADDRR) usesVariableSpace() bool {
+	return false
+}
+
+func (a ADDRR) String() string {
+	return fmt.Sprintf("ADDRR V%X V%X", a.Register, a.AmountRegister)
+}
@@ -1,11 +1,13 @@
 package ir
 
+import "fmt"
+
 /*
 BNE is a simple structure that will skips the next instruction
-if lhs does not equal rhs
+if lhs equals rhs, so a jump after it is taken when they are not equal
 
-opcode: 4XNN
-4: identifier
+opcode: 3XNN
+3: identifier
 X: lhs
 NN: rhs
 */
@@ -25,6 +27,10 @@ func (b BNE) usesVariableSpace() bool {
 	return false
 }
 
+func (b BNE) String() string {
+	return fmt.Sprintf("BNE V%X %d", b.Lhs, b.Rhs)
+}
+
 /*
 	BNERR is the same as BNE but the RHS is also a register
 
@@ -49,6 +55,68 @@ func (b BNERR) usesVariableSpace() bool {
 	return false
 }
 
+func (b BNERR) String() string {
+	return fmt.Sprintf("BNERR V%X V%X", b.Lhs, b.Rhs)
+}
+
+/*
+BEQ is the opposite of BNE. it skips the next instruction
+if lhs does not equal rhs, so a jump after it is taken when they are equal
+
+opcode: 4XNN
+4: identifier
+X: lhs
+NN: rhs
+*/
+type BEQ struct {
+	Lhs, Rhs int
+}
+
+func (b BEQ) GetInstructionName() string {
+	return "BEQ"
+}
+
+func (b BEQ) Opcodeable() bool {
+	return true
+}
+
+func (b BEQ) usesVariableSpace() bool {
+	return false
+}
+
+func (b BEQ) String() string {
+	return fmt.Sprintf("BEQ V%X %d", b.Lhs, b.Rhs)
+}
+
+/*
+BEQRR is the opposite of BNERR. it skips the next instruction
+if lhs does not equal rhs, so a jump after it is taken when they are equal
+
+opcode: 9XY0
+9: identifier
+X: lhs register
+Y: rhs register
+*/
+type BEQRR struct {
+	Lhs, Rhs int
+}
+
+func (b BEQRR) GetInstructionName() string {
+	return "BEQRR"
+}
+
+func (b BEQRR) Opcodeable() bool {
+	return true
+}
+
+func (b BEQRR) usesVariableSpace() bool {
+	return false
+}
+
+func (b BEQRR) String() string {
+	return fmt.Sprintf("BEQRR V%X V%X", b.Lhs, b.Rhs)
+}
+
 func (g *Generator) newBNEInstructionFromLoose(R1 int, rhs int) BNE {
 	instr := BNE{R1, rhs}
 
@@ -21,7 +21,7 @@ compressMemoryLayout relocates all variables next to the opcodes to reduce the s
 // 				cast := g.Ir[i].(SET)
 // 				cast.Addr = newPostion
 // 				memTableVariable := g.memTable.FindByAddr(cast.Addr)
-// 				g.memTable.Move(memTableVariable, newPostion, true)
+// 				g.memTable.Move(memTableVariable, newPostion)
 // 				variablesReplaced++
 // 				break
 // 			case "MOV":
@@ -29,7 +29,7 @@ compressMemoryLayout relocates all variables next to the opcodes to reduce the s
 // 				cast := g.Ir[i].(MOV)
 // 				cast.R2 = newPostion
 // 				memTableVariable := g.memTable.FindByAddr(cast.R2)
-// 				g.memTable.Move(memTableVariable, newPostion, true)
+// 				g.memTable.Move(memTableVariable, newPostion)
 // 				variablesReplaced++
 // 				break
 // 			}
@@ -0,0 +1,292 @@
+package ir
+
+import (
+	"strconv"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/errors"
+)
+
+//flagRegister is VF, which subtractions set to 1 when nothing had to be borrowed
+const flagRegister = 0xF
+
+//temporaryRegisterName marks a register that holds part of an expression. a '.' cannot be part of a smol name
+const temporaryRegisterName = ".temporary"
+
+//operand is a side of a condition, a register or a number when register is -1.
+//temporary is set when the register only holds an intermediate result and can be released once it is used
+type operand struct {
+	register, value int
+	temporary       bool
+}
+
+func (o operand) isLiteral() bool {
+	return o.register == -1
+}
+
+//fitsRegister checks if o is a register or a number a register can hold
+func (o operand) fitsRegister() bool {
+	return !o.isLiteral() || (o.value >= 0 && o.value <= 0xFF)
+}
+
+//resolveOperand resolves a side of a condition
+func (g *Generator) resolveOperand(node ast.Node) operand {
+	switch node.GetNodeName() {
+	case "numLit":
+		value, _ := strconv.Atoi(node.(*ast.NumLit).Value)
+		return operand{register: -1, value: value}
+	case "boolLit":
+		if node.(*ast.BoolLit).Value == "True" {
+			return operand{register: -1, value: 1}
+		}
+		return operand{register: -1, value: 0}
+	case "statVar":
+		return operand{register: g.findVariable(node.(*ast.StatVar))}
+	case "expression":
+		switch expression := node.(type) {
+		case ast.Expression:
+			return g.resolveExpression(&expression)
+		case *ast.Expression:
+			return g.resolveExpression(expression)
+		}
+	}
+	g.failAt(errors.UnsupportedOperationError("comparing a "+node.GetNodeName()), node)
+	return operand{}
+}
+
+/*
+resolveExpression works an expression in RPN form out into a register.
+Every intermediate result gets a register of its own that is released once it has been used.
+Parts that only hold numbers are worked out here, no code is needed for them.
+
+	a + 1   ADD VX 1
+	a - 1   ADD VX 255, registers wrap around so this takes 1 off
+	a + b   ADDRR VX VY
+	a - b   SUB VX VY
+
+chip-8 cannot multiply or divide, so only + and - can be compiled
+*/
+func (g *Generator) resolveExpression(expression *ast.Expression) operand {
+	stack := []operand{}
+	for _, token := range expression.Tokens {
+		switch token.Type {
+		case "integer":
+			value, _ := strconv.Atoi(token.Value)
+			stack = append(stack, operand{register: -1, value: value})
+		case "boolean_keyword":
+			value := 0
+			if token.Value == "True" {
+				value = 1
+			}
+			stack = append(stack, operand{register: -1, value: value})
+		case "character", "string":
+			register := g.lookupVariable(token.Value)
+			if register == -1 {
+				g.failAt(errors.UndefinedVariableError(token.Value), expression)
+			}
+			stack = append(stack, operand{register: register})
+		case "plus", "dash":
+			if len(stack) < 2 {
+				g.failAt(errors.ExpressionAbortError(), expression)
+			}
+			lhs, rhs := stack[len(stack)-2], stack[len(stack)-1]
+			stack = append(stack[:len(stack)-2], g.applyOperator(token.Type, lhs, rhs))
+		default:
+			g.failAt(errors.UnsupportedOperationError(token.Value+" in a compiled condition").
+				WithHelp("only + and - can be compiled. -interpret runs the program as is"), expression)
+		}
+	}
+
+	if len(stack) != 1 {
+		g.failAt(errors.ExpectedExpressionError(), expression)
+	}
+	return stack[0]
+}
+
+//applyOperator adds rhs onto lhs or takes it off. the result is put in a temporary register unless both are numbers
+func (g *Generator) applyOperator(operator string, lhs operand, rhs operand) operand {
+	if lhs.isLiteral() && rhs.isLiteral() {
+		if operator == "plus" {
+			return operand{register: -1, value: lhs.value + rhs.value}
+		}
+		return operand{register: -1, value: lhs.value - rhs.value}
+	}
+
+	result := g.temporaryCopy(lhs)
+	switch {
+	case rhs.isLiteral() && operator == "plus":
+		g.Ir = append(g.Ir, g.newAddInstruction(result.register, rhs.value&0xFF))
+	case rhs.isLiteral():
+		g.Ir = append(g.Ir, g.newAddInstruction(result.register, (0x100-rhs.value&0xFF)&0xFF))
+	case operator == "plus":
+		g.Ir = append(g.Ir, ADDRR{result.register, rhs.register})
+	default:
+		g.Ir = append(g.Ir, SUB{result.register, rhs.register})
+	}
+	g.release(rhs)
+	return result
+}
+
+//temporaryCopy returns o when it is already temporary and a temporary register holding its value when it is not
+func (g *Generator) temporaryCopy(o operand) operand {
+	if o.temporary {
+		return o
+	}
+
+	register := -1
+	if g.function != "" {
+		register = g.regTable.Find(g.function + temporaryRegisterName)
+	}
+	if register == -1 {
+		register = g.findEmptyRegister()
+	}
+	g.regTable.PutRegisterValue(register, 0, temporaryRegisterName)
+	if o.isLiteral() {
+		g.Ir = append(g.Ir, SETREG{Index: register, Val: o.value})
+	} else {
+		g.Ir = append(g.Ir, g.newRegCpy(o.register, register))
+	}
+	return operand{register: register, temporary: true}
+}
+
+/*
+release frees the registers of temporary operands so they can be used again.
+A function can be called after the code that follows it took those registers,
+so inside a function they are only given back to that function
+*/
+func (g *Generator) release(operands ...operand) {
+	name := ""
+	if g.function != "" {
+		name = g.function + temporaryRegisterName
+	}
+	for _, o := range operands {
+		if o.temporary {
+			g.regTable.PutRegisterValue(o.register, 0, name)
+		}
+	}
+}
+
+/*
+jumpUnless jumps to label when condition does not hold and falls through when it does.
+chip-8 can only skip the instruction after a test, so every condition is a skip over the jump.
+
+	a == b  skip if a equals b (3XNN, 5XY0)
+	a != b  skip if a does not equal b (4XNN, 9XY0)
+	a < b   subtract b from a copy of a (8XY5, 8XY7) and skip if VF says something was borrowed (3F00)
+	a > b   the same as b < a
+	a >= b  subtract b from a copy of a and skip if VF says nothing was borrowed (3F01)
+	a <= b  the same as b >= a
+
+the copy is made in the BNEX register so the variables themselves are not changed.
+Conditions on two numbers are worked out here, no code is needed for them.
+Neither is any for a number that does not fit in a register, like a < 300, which always holds
+*/
+func (g *Generator) jumpUnless(condition *ast.Condition, label string) {
+	left := g.resolveOperand(condition.Left)
+	right := operand{register: -1, value: 0}
+	operator := condition.Operator
+	if operator == "" {
+		//a value on its own holds when it is not zero
+		operator = "!="
+	} else {
+		right = g.resolveOperand(condition.Right)
+	}
+	defer g.release(left, right)
+
+	//a register holds 0 to 255, so compared with a number outside of that
+	//the outcome is the same whatever is in it. any value in range works it out
+	if !left.fitsRegister() || !right.fitsRegister() {
+		if !left.isLiteral() {
+			left = operand{register: -1, value: 0}
+		}
+		if !right.isLiteral() {
+			right = operand{register: -1, value: 0}
+		}
+	}
+
+	if left.isLiteral() && right.isLiteral() {
+		if !holds(operator, left.value, right.value) {
+			g.Ir = append(g.Ir, g.newJumpToLabel(label))
+		}
+		return
+	}
+
+	switch operator {
+	case "==":
+		g.skipIfEqual(left, right)
+	case "!=":
+		g.skipIfNotEqual(left, right)
+	case "<":
+		g.greaterOrEqual(left, right)
+		g.Ir = append(g.Ir, g.newBNEInstructionFromLoose(flagRegister, 0))
+	case ">":
+		g.greaterOrEqual(right, left)
+		g.Ir = append(g.Ir, g.newBNEInstructionFromLoose(flagRegister, 0))
+	case ">=":
+		g.greaterOrEqual(left, right)
+		g.Ir = append(g.Ir, g.newBNEInstructionFromLoose(flagRegister, 1))
+	case "<=":
+		g.greaterOrEqual(right, left)
+		g.Ir = append(g.Ir, g.newBNEInstructionFromLoose(flagRegister, 1))
+	default:
+		g.failAt(errors.UnsupportedOperationError("comparison with "+condition.Operator), condition)
+	}
+	g.Ir = append(g.Ir, g.newJumpToLabel(label))
+}
+
+//holds works out a comparison of two numbers
+func holds(operator string, left int, right int) bool {
+	switch operator {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	case "<":
+		return left < right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	case "<=":
+		return left <= right
+	}
+	return false
+}
+
+//skipIfEqual skips the next instruction when a equals b. at least one of them is a register
+func (g *Generator) skipIfEqual(a operand, b operand) {
+	switch {
+	case a.isLiteral():
+		g.Ir = append(g.Ir, g.newBNEInstructionFromLoose(b.register, a.value))
+	case b.isLiteral():
+		g.Ir = append(g.Ir, g.newBNEInstructionFromLoose(a.register, b.value))
+	default:
+		g.Ir = append(g.Ir, g.newBNERRInstructionFromLoose(a.register, b.register))
+	}
+}
+
+//skipIfNotEqual skips the next instruction when a does not equal b. at least one of them is a register
+func (g *Generator) skipIfNotEqual(a operand, b operand) {
+	switch {
+	case a.isLiteral():
+		g.Ir = append(g.Ir, BEQ{b.register, a.value})
+	case b.isLiteral():
+		g.Ir = append(g.Ir, BEQ{a.register, b.value})
+	default:
+		g.Ir = append(g.Ir, BEQRR{a.register, b.register})
+	}
+}
+
+//greaterOrEqual sets VF to 1 when a is at least b and to 0 when it is not. at least one of them is a register
+func (g *Generator) greaterOrEqual(a operand, b operand) {
+	scratch := g.BNEXRegister
+	switch {
+	case a.isLiteral():
+		g.Ir = append(g.Ir, SETREG{Index: scratch, Val: a.value}, SUB{scratch, b.register})
+	case b.isLiteral():
+		//scratch becomes a - b
+		g.Ir = append(g.Ir, SETREG{Index: scratch, Val: b.value}, SUBN{scratch, a.register})
+	default:
+		g.Ir = append(g.Ir, g.newRegCpy(a.register, scratch), SUB{scratch, b.register})
+	}
+}
@@ -0,0 +1,66 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+/*
+Dump renders the IR in its canonical text form, one instruction per line.
+Registers that hold a named value in the register table get the name
+as a comment
+
+	SETREG V0 10 ; V0 = a
+*/
+func (g *Generator) Dump() string {
+	var out bytes.Buffer
+	for _, instr := range g.Ir {
+		out.WriteString(instr.String())
+
+		names := []string{}
+		for _, register := range registerOperands(instr) {
+			if name := g.regTable[register].Name; name != "" {
+				names = append(names, fmt.Sprintf("V%X = %s", register, name))
+			}
+		}
+		if len(names) > 0 {
+			out.WriteString(" ; " + strings.Join(names, ", "))
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+//registerOperands lists the general purpose registers an instruction uses
+func registerOperands(instr instruction) []int {
+	switch i := instr.(type) {
+	case SETREG:
+		return []int{i.Index}
+	case RegCpy:
+		return []int{i.From, i.To}
+	case ADD:
+		return []int{i.Register}
+	case ADDRR:
+		return []int{i.Register, i.AmountRegister}
+	case SUB:
+		return []int{i.TargetRegister, i.AmountRegister}
+	case SUBN:
+		return []int{i.TargetRegister, i.AmountRegister}
+	case BNE:
+		return []int{i.Lhs}
+	case BEQ:
+		return []int{i.Lhs}
+	case BNERR:
+		return []int{i.Lhs, i.Rhs}
+	case BEQRR:
+		return []int{i.Lhs, i.Rhs}
+	case PLOT:
+		return []int{i.X, i.Y}
+	case MOV:
+		if !i.ANNN {
+			return []int{i.R1}
+		}
+	}
+	return []int{}
+}
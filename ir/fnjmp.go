@@ -1,6 +1,11 @@
 package ir
 
-import "github.com/fabulousduck/smol/ast"
+import (
+	"fmt"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/errors"
+)
 
 /*
 FNJMP is a special jump for the chip-8
@@ -9,9 +14,12 @@ that is meant for function calls
 2NNN
 
 NNN: address of the function on memory
+
+when Label is set the call goes to that label and Addr is ignored
 */
 type FNJMP struct {
-	addr int
+	Addr  int
+	Label string
 }
 
 func (f FNJMP) GetInstructionName() string {
@@ -26,14 +34,60 @@ func (f FNJMP) usesVariableSpace() bool {
 	return false
 }
 
-func (g *Generator) newFNJMPInstruction(jmpAddr int) FNJMP {
-	return FNJMP{jmpAddr}
+func (f FNJMP) String() string {
+	if f.Label != "" {
+		return "FNJMP " + f.Label
+	}
+	return fmt.Sprintf("FNJMP 0x%03X", f.Addr)
+}
+
+func (g *Generator) newFNJMPInstruction(label string) FNJMP {
+	return FNJMP{Label: label}
 }
 
 func (g *Generator) createFunctionCallInstructions(instruction *ast.FunctionCall) {
 
 	//lookup the function on the function table
-	fnTableEntry := g.functionAddrTable.Find(instruction.Name)
+	fnTableEntry, ok := g.functionAddrTable.Find(instruction.Name)
+	if !ok {
+		g.fail(errors.UnknownFunctionName(instruction.Name).WithHelp("a function has to be defined before the first call to it"))
+	}
+	if len(instruction.Args) != len(fnTableEntry.Params) {
+		g.fail(errors.IncorrectFunctionParamCountError(instruction.Name, len(instruction.Args), len(fnTableEntry.Params)))
+	}
+
+	/*
+		work out every argument before any of them is copied into the registers of the parameters.
+		an argument that is a parameter of the function itself, like in a call to f(y, x) from f(x, y),
+		would be overwritten by the copies before it is read, so it is copied into a temporary register first
+	*/
+	values := []operand{}
+	for i, arg := range instruction.Args {
+		value := g.resolveOperand(arg)
+		if !value.isLiteral() && !value.temporary && value.register != fnTableEntry.Params[i] && isParam(value.register, fnTableEntry.Params) {
+			value = g.temporaryCopy(value)
+		}
+		values = append(values, value)
+	}
 
-	g.Ir = append(g.Ir, g.newFNJMPInstruction(fnTableEntry.Addr))
+	for i, value := range values {
+		if value.isLiteral() {
+			g.Ir = append(g.Ir, SETREG{Index: fnTableEntry.Params[i], Val: value.value})
+		} else if value.register != fnTableEntry.Params[i] {
+			g.Ir = append(g.Ir, g.newRegCpy(value.register, fnTableEntry.Params[i]))
+		}
+	}
+	g.release(values...)
+
+	g.Ir = append(g.Ir, g.newFNJMPInstruction(fnTableEntry.Label))
+}
+
+//isParam checks if register holds one of params
+func isParam(register int, params []int) bool {
+	for _, param := range params {
+		if param == register {
+			return true
+		}
+	}
+	return false
 }
@@ -1,40 +1,35 @@
 package functionaddrtable
 
-import (
-	"os"
-
-	"github.com/fabulousduck/smol/errors"
-)
-
 //FunctionAddrTable is a simple type so we can do function mounting on it
 type FunctionAddrTable []FunctionAddr
 
 /*
 FunctionAddr stores basic information about a function
-and where it is stored in memory
+and the label that marks where it is stored in memory.
+Params are the registers its parameters are in, in the order they are defined
 */
 type FunctionAddr struct {
-	Addr int
-	Name string
+	Label  string
+	Name   string
+	Params []int
 }
 
 /*
 NewFunctionAddr returns a new filled FunctionAddr struct
 */
-func NewFunctionAddr(addr int, name string) FunctionAddr {
-	return FunctionAddr{addr, name}
+func NewFunctionAddr(label string, name string, params []int) FunctionAddr {
+	return FunctionAddr{label, name, params}
 }
 
 /*
 Find checks if a given function with name name exists in the function table
+ok is false when it does not
 */
-func (table FunctionAddrTable) Find(name string) FunctionAddr {
+func (table FunctionAddrTable) Find(name string) (FunctionAddr, bool) {
 	for i := 0; i < len(table); i++ {
 		if table[i].Name == name {
-			return table[i]
+			return table[i], true
 		}
 	}
-	errors.UnknownFunctionName(name)
-	os.Exit(65)
-	return FunctionAddr{}
+	return FunctionAddr{}, false
 }
@@ -0,0 +1,58 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/fabulousduck/smol/ast"
+)
+
+/*
+createIfStatementInstructions lays an if statement out as
+
+		Jump if.N.else unless the condition holds
+		body
+		Jump if.N.end
+	if.N.else:
+		else
+	if.N.end:
+
+without an else the condition jumps straight to if.N.end.
+an elif is an if statement in the else, so it gets labels of its own
+*/
+func (g *Generator) createIfStatementInstructions(ifStatement *ast.IfStatement) {
+	g.ifCount++
+	endLabel := fmt.Sprintf("if.%d.end", g.ifCount)
+	if ifStatement.Else == nil {
+		g.jumpUnless(ifStatement.Condition, endLabel)
+		g.Generate(ifStatement.Body)
+		g.Ir = append(g.Ir, g.newLabel(endLabel))
+		return
+	}
+
+	elseLabel := fmt.Sprintf("if.%d.else", g.ifCount)
+	g.jumpUnless(ifStatement.Condition, elseLabel)
+	g.Generate(ifStatement.Body)
+	g.Ir = append(g.Ir, g.newJumpToLabel(endLabel))
+
+	g.Ir = append(g.Ir, g.newLabel(elseLabel))
+	g.Generate(ifStatement.Else)
+	g.Ir = append(g.Ir, g.newLabel(endLabel))
+}
+
+/*
+createComparisonBlockInstructions lays eq, neq, gt and lt blocks out like an if without an else.
+the end label is named after the block, eq.N.end for eq
+
+	eq   BNE, BNERR
+	neq  BEQ, BEQRR
+	gt   8XY5 or 8XY7 and BNE VF 0
+	lt   the same as gt with the sides swapped
+*/
+func (g *Generator) createComparisonBlockInstructions(name string, condition *ast.Condition, body []ast.Node) {
+	g.comparisonBlockCount++
+	endLabel := fmt.Sprintf("%s.%d.end", name, g.comparisonBlockCount)
+
+	g.jumpUnless(condition, endLabel)
+	g.Generate(body)
+	g.Ir = append(g.Ir, g.newLabel(endLabel))
+}
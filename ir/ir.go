@@ -1,9 +1,8 @@
 package ir
 
 import (
-	"os"
-
 	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
 	"github.com/fabulousduck/smol/errors"
 	"github.com/fabulousduck/smol/ir/functionaddrtable"
 	"github.com/fabulousduck/smol/ir/memtable"
@@ -16,11 +15,17 @@ type instruction interface {
 	GetInstructionName() string
 	Opcodeable() bool
 	usesVariableSpace() bool
+	String() string //String gives the canonical text form of the instruction
 }
 
 //Generator contains all the basic information needed
-//to transform an AST into a chip-8 ROM
+//to transform an AST into a chip-8 ROM.
+//problems found while generating are added to Diagnostics
+//Lines is the debug line table that maps the instructions in Ir back to the source
 type Generator struct {
+	Diagnostics                                  *diag.List
+	Lines                                        []Line
+	generating                                   []ast.Node
 	filename                                     string
 	functionAddrTable                            functionaddrtable.FunctionAddrTable
 	nodesConsumed                                int
@@ -28,6 +33,13 @@ type Generator struct {
 	functionSpaceStart                           int
 	IRegisterIndex, plotXRegister, plotYRegister int
 	BNEXRegister                                 int
+	asmBlockCount                                int
+	loopCount                                    int
+	ifCount                                      int
+	comparisonBlockCount                         int
+	variableRegisters                            map[string]int
+	function                                     string
+	params                                       map[string]int
 	Ir                                           []instruction
 	memTable                                     memtable.MemTable
 	regTable                                     registertable.RegisterTable
@@ -38,6 +50,8 @@ func NewGenerator(filename string) *Generator {
 	g := new(Generator)
 	g.memTable = make(memtable.MemTable)
 	g.regTable = make(registertable.RegisterTable)
+	g.variableRegisters = make(map[string]int)
+	g.Diagnostics = new(diag.List)
 	g.filename = filename
 	g.nodesConsumed = 0
 	g.functionSpaceStart = 0xA00
@@ -51,6 +65,75 @@ func NewGenerator(filename string) *Generator {
 	return g
 }
 
+//generateError is used to unwind the generator to the node it was generating once an error has been reported
+type generateError struct{}
+
+//fail reports d at the node that is being generated and stops generating it
+func (g *Generator) fail(d diag.Diagnostic) {
+	if d.Pos.Line == 0 && len(g.generating) > 0 {
+		d = at(d, g.generating[len(g.generating)-1])
+	}
+	g.Diagnostics.Add(d.InFile(g.filename))
+	panic(generateError{})
+}
+
+//failAt reports d at a part of the node that is being generated, like the name of a variable that does not exist
+func (g *Generator) failAt(d diag.Diagnostic, node ast.Node) {
+	g.fail(at(d, node))
+}
+
+//at points d at node when it is known where node is
+func at(d diag.Diagnostic, node ast.Node) diag.Diagnostic {
+	span := node.GetSpan()
+	if span.Start.Line == 0 {
+		return d
+	}
+	return d.Between(span.Start, span.End)
+}
+
+//recoverNode stops the unwinding started by fail so the next node can be generated
+func (g *Generator) recoverNode() {
+	if r := recover(); r != nil {
+		if _, ok := r.(generateError); !ok {
+			panic(r)
+		}
+	}
+}
+
+//findVariable returns the register variable is stored in
+func (g *Generator) findVariable(variable *ast.StatVar) int {
+	register := g.lookupVariable(variable.Value)
+	if register == -1 {
+		g.failAt(errors.UndefinedVariableError(variable.Value), variable)
+	}
+	return register
+}
+
+//lookupVariable returns the register a variable or a parameter of the function being generated is in. -1 when there is none
+func (g *Generator) lookupVariable(name string) int {
+	if register, ok := g.params[name]; ok {
+		return register
+	}
+	return g.regTable.Find(name)
+}
+
+//scoped prefixes name with the function being generated, like inc.a, so names in different functions do not collide
+func (g *Generator) scoped(name string) string {
+	if g.function == "" {
+		return name
+	}
+	return g.function + "." + name
+}
+
+//findEmptyRegister returns a register that is free to use
+func (g *Generator) findEmptyRegister() int {
+	register := g.regTable.FindEmptyRegister()
+	if register == -1 {
+		g.fail(errors.OutOfRegistersError(registertable.GeneralPurposeCount(), g.regTable.Names()))
+	}
+	return register
+}
+
 /*
 FindInstructionIndex looks up an instruction with given ID.
 returns the first one it finds
@@ -63,7 +146,7 @@ only instructions that have ID's
 func (g *Generator) FindInstructionIndex(ID string) int {
 	for i := 0; i < len(g.Ir); i++ {
 		if g.Ir[i].GetInstructionName() == "Jump" {
-			jumpInstrCast := g.Ir[i].(*Jump)
+			jumpInstrCast := g.Ir[i].(Jump)
 			if jumpInstrCast.ID == ID {
 				return i
 			}
@@ -73,39 +156,69 @@ func (g *Generator) FindInstructionIndex(ID string) int {
 }
 
 /*
-Generate interprets the AST and makes an IR from it
+Generate interprets the AST and makes an IR from it.
+A node that cannot be generated is reported and skipped
 */
 func (g *Generator) Generate(AST []ast.Node) {
-	for i := 0; i < len(AST); i++ {
-		nodeType := AST[i].GetNodeName()
-		switch nodeType {
-		case "variable":
-			variable := AST[i].(*ast.Variable)
-			g.createVariableOperationInstructions(variable)
-		case "statement":
-			statement := AST[i].(*ast.Statement)
-			g.Ir = append(g.Ir, g.handleStatement(statement))
-		case "function":
-			instruction := AST[i].(*ast.Function)
-			g.createFunctionInstructions(instruction)
-		case "directOperation":
-			instruction := AST[i].(*ast.DirectOperation)
-			g.createDirectOperationInstructions(instruction)
-		case "functionCall":
-			instruction := AST[i].(*ast.FunctionCall)
-			g.createFunctionCallInstructions(instruction)
-		case "setStatement":
-			instruction := AST[i].(*ast.SetStatement)
-			g.createSetStatement(instruction)
-		case "freeStatement":
-			instruction := AST[i].(*ast.FreeStatement)
-			g.doFreeInstruction(instruction)
-		case "switchStatement":
-
-		case "plotStatement":
-			plotStatement := AST[i].(*ast.PlotStatement)
-			g.Ir = append(g.Ir, g.newPlotInstructionSet(plotStatement))
-		}
+	for _, node := range AST {
+		g.generateNode(node)
+	}
+}
+
+func (g *Generator) generateNode(node ast.Node) {
+	defer g.recoverNode()
+	g.enter(node)
+	defer g.leave()
+
+	nodeType := node.GetNodeName()
+	switch nodeType {
+	case "variable":
+		variable := node.(*ast.Variable)
+		g.createVariableOperationInstructions(variable)
+	case "statement":
+		statement := node.(*ast.Statement)
+		g.Ir = append(g.Ir, g.handleStatement(statement))
+	case "function":
+		instruction := node.(*ast.Function)
+		g.createFunctionInstructions(instruction)
+	case "directOperation":
+		instruction := node.(*ast.DirectOperation)
+		g.createDirectOperationInstructions(instruction)
+	case "functionCall":
+		instruction := node.(*ast.FunctionCall)
+		g.createFunctionCallInstructions(instruction)
+	case "setStatement":
+		instruction := node.(*ast.SetStatement)
+		g.createSetStatement(instruction)
+	case "freeStatement":
+		instruction := node.(*ast.FreeStatement)
+		g.doFreeInstruction(instruction)
+	case "switchStatement":
+
+	case "plotStatement":
+		plotStatement := node.(*ast.PlotStatement)
+		g.Ir = append(g.Ir, g.newPlotInstructionSet(plotStatement))
+	case "asmBlock":
+		asmBlock := node.(*ast.AsmBlock)
+		g.createAsmBlockInstructions(asmBlock)
+	case "whileLoop":
+		loop := node.(*ast.WhileLoop)
+		g.createWhileLoopInstructions(loop)
+	case "IfStatement":
+		ifStatement := node.(*ast.IfStatement)
+		g.createIfStatementInstructions(ifStatement)
+	case "eqBlock":
+		block := node.(*ast.EqBlock)
+		g.createComparisonBlockInstructions("eq", block.Condition(), block.Body)
+	case "neqBlock":
+		block := node.(*ast.NeqBlock)
+		g.createComparisonBlockInstructions("neq", block.Condition(), block.Body)
+	case "gtBlock":
+		block := node.(*ast.GtBlock)
+		g.createComparisonBlockInstructions("gt", block.Condition(), block.Body)
+	case "ltBlock":
+		block := node.(*ast.LtBlock)
+		g.createComparisonBlockInstructions("lt", block.Condition(), block.Body)
 	}
 }
 
@@ -113,54 +226,92 @@ func (g *Generator) Generate(AST []ast.Node) {
 //it simply changes the internal compiler register table
 func (g *Generator) doFreeInstruction(instruction *ast.FreeStatement) {
 	variable := instruction.Variable.(*ast.StatVar)
-	register := g.regTable.Find(variable.Value)
+	register := g.findVariable(variable)
 	g.regTable.PutRegisterValue(register, 0, "")
 }
 
 func (g *Generator) createFunctionInstructions(instruction *ast.Function) {
-
-	beforeGenerationInstructionCount := len(g.Ir)
-	//find the location where the function will be placed
+	//labels are resolved by the bytecode generator once it knows where everything ends up.
+	//a '.' cannot be part of a smol name so the end label never collides with a function
+	startLabel := instruction.Name
+	endLabel := instruction.Name + ".end"
 
 	//create the jump instruction so it knows to jump over the function
 	//when not called
-	passJumpInstruction := g.newJumpInstructionFromLoose(0)
-
-	uid := uuid.New()
-	passJumpInstructionID := uid.String()
-	passJumpInstruction.ID = passJumpInstructionID
+	passJumpInstruction := g.newJumpToLabel(endLabel)
+	passJumpInstruction.ID = uuid.New().String()
+	g.Ir = append(g.Ir, passJumpInstruction)
 
-	//save the byte addr before generating function code
-	functionStartAddr := 0x200 + (beforeGenerationInstructionCount * 2)
+	//every parameter gets a register of its own that a call copies its argument into.
+	//the register is named after the function as well so it does not hide a variable with the same name outside of it
+	params := map[string]int{}
+	paramRegisters := []int{}
+	for _, param := range instruction.Params {
+		register := g.findEmptyRegister()
+		g.regTable.PutRegisterValue(register, 0, instruction.Name+"."+param)
+		params[param] = register
+		g.variableRegisters[instruction.Name+"."+param] = register
+		paramRegisters = append(paramRegisters, register)
+	}
 
 	//put a new function on the function table so we know where can jump to to call it
-	g.functionAddrTable = append(g.functionAddrTable, functionaddrtable.NewFunctionAddr(functionStartAddr, instruction.Name))
+	g.functionAddrTable = append(g.functionAddrTable, functionaddrtable.NewFunctionAddr(startLabel, instruction.Name, paramRegisters))
+	g.Ir = append(g.Ir, g.newLabel(startLabel))
 
 	//generate the function code
+	outerFunction, outerParams := g.function, g.params
+	g.function, g.params = instruction.Name, params
 	g.Generate(instruction.Body)
-
-	//find the jump back
-	passJumpInstrIndex := g.FindInstructionIndex(passJumpInstructionID)
-
-	//replace it with the new one that contains the proper address
-	g.Ir[passJumpInstrIndex] = Jump{To: 0x200 + (len(g.Ir) * 2), ID: passJumpInstructionID}
+	g.function, g.params = outerFunction, outerParams
 
 	//put in a return statement
 	g.Ir = append(g.Ir, g.newRetInstruction())
+	g.Ir = append(g.Ir, g.newLabel(endLabel))
 }
 
-func (g *Generator) createDirectOperationInstructions(do *ast.DirectOperation) instruction {
+func (g *Generator) createDirectOperationInstructions(do *ast.DirectOperation) {
 	if !ast.NodeIsVariable(do.Variable) {
-		errors.LitIncrementError()
-		os.Exit(65)
+		g.fail(errors.LitIncrementError())
 	}
 	rhsVariable := do.Variable.(*ast.StatVar)
-	variableRegisterTableIndex := g.regTable.Find(rhsVariable.Value)
+	variableRegisterTableIndex := g.findVariable(rhsVariable)
 	if do.Operation == "++" {
-		return g.newAddInstruction(variableRegisterTableIndex, 1)
+		g.Ir = append(g.Ir, g.newAddInstruction(variableRegisterTableIndex, 1))
+		return
 	}
 
-	return g.newSubInstruction(variableRegisterTableIndex, 1)
+	//newSubInstruction embeds its own instructions
+	g.newSubInstruction(variableRegisterTableIndex, 1)
+}
+
+//RegisterTable returns the register allocation the generator has made so far
+func (g *Generator) RegisterTable() registertable.RegisterTable {
+	return g.regTable
+}
+
+/*
+VariableRegister returns the register a variable was put in when it was declared.
+Variables declared in a function and its parameters are named function.name.
+Unlike the register table this still knows about variables that have been freed
+*/
+func (g *Generator) VariableRegister(name string) (int, bool) {
+	register, ok := g.variableRegisters[name]
+	return register, ok
+}
+
+//Variables returns the register every variable and parameter was put in, by the name VariableRegister takes
+func (g *Generator) Variables() map[string]int {
+	return g.variableRegisters
+}
+
+//MemTable returns the variables that were placed in variable space. their addresses are relative to the start of the ROM
+func (g *Generator) MemTable() memtable.MemTable {
+	return g.memTable
+}
+
+//Functions returns every function that was defined and the label it starts at
+func (g *Generator) Functions() functionaddrtable.FunctionAddrTable {
+	return g.functionAddrTable
 }
 
 func (g *Generator) handleStatement(s *ast.Statement) instruction {
@@ -169,11 +320,10 @@ func (g *Generator) handleStatement(s *ast.Statement) instruction {
 	case "INC":
 
 		if !ast.NodeIsVariable(s.RHS) {
-			errors.LitIncrementError()
-			os.Exit(65)
+			g.fail(errors.LitIncrementError())
 		}
 		rhsVariable := s.RHS.(*ast.StatVar)
-		variableRegisterTableIndex := g.regTable.Find(rhsVariable.Value)
+		variableRegisterTableIndex := g.findVariable(rhsVariable)
 		instr = g.newAddInstruction(variableRegisterTableIndex, 1)
 	}
 	return instr
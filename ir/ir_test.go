@@ -0,0 +1,267 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/lexer"
+)
+
+func generate(program string) *Generator {
+	l := lexer.NewLexer("TESTING", program)
+	l.Lex()
+	p := ast.NewParser("TESTING", l.Tokens)
+	nodes, _ := p.Parse("")
+	g := NewGenerator("TESTING")
+	g.Generate(nodes)
+	return g
+}
+
+func TestDump(T *testing.T) {
+	programs := []struct {
+		name, program, expected string
+	}{
+		{
+			"plot",
+			"Uint32 x = 10\nUint32 y = 10\nplot(x,y)\n",
+			"SETREG V0 10 ; V0 = x\n" +
+				"SETREG V1 10 ; V1 = y\n" +
+				"SETMEM 0xCA0 128\n" +
+				"MOV I 0xCA0\n" +
+				"RegCpy V0 VE ; V0 = x\n" +
+				"RegCpy V1 VD ; V1 = y\n" +
+				"PLOT VE VD 1\n",
+		},
+		{
+			"directOperation",
+			"Uint32 a = 10\na++\na--\n",
+			"SETREG V0 10 ; V0 = a\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"SETREG V1 1\n" +
+				"SUB V0 V1 ; V0 = a\n" +
+				"SETREG V1 0\n",
+		},
+		{
+			"function",
+			"def f(a):\n    Uint32 b = 1\nend\nf(1)\n",
+			"Jump f.end\n" +
+				"f:\n" +
+				"SETREG V1 1 ; V1 = b\n" +
+				"RET\n" +
+				"f.end:\n" +
+				"SETREG V0 1 ; V0 = f.a\n" +
+				"FNJMP f\n",
+		},
+		{
+			"outOfRangeComparison",
+			"Uint32 a = 0\nif(a < 300):\n    a++\nend\nwhile(a == 256):\n    a++\nend\n",
+			"SETREG V0 0 ; V0 = a\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"if.1.end:\n" +
+				"while.1:\n" +
+				"Jump while.1.end\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"Jump while.1\n" +
+				"while.1.end:\n",
+		},
+		{
+			"ifStatement",
+			"Uint32 a = 0\nif(a >= 3):\n    a++\nelif(a != 1):\n    a++\nelse:\n    a--\nend\n",
+			"SETREG V0 0 ; V0 = a\n" +
+				"SETREG VC 3\n" +
+				"SUBN VC V0 ; V0 = a\n" +
+				"BNE VF 1\n" +
+				"Jump if.1.else\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"Jump if.1.end\n" +
+				"if.1.else:\n" +
+				"BEQ V0 1 ; V0 = a\n" +
+				"Jump if.2.else\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"Jump if.2.end\n" +
+				"if.2.else:\n" +
+				"SETREG V1 1\n" +
+				"SUB V0 V1 ; V0 = a\n" +
+				"SETREG V1 0\n" +
+				"if.2.end:\n" +
+				"if.1.end:\n",
+		},
+		{
+			"comparisonBlocks",
+			"Uint32 a = 1\nUint32 b = 2\neq(a, b):\n    a++\nend\nneq(a, b):\n    a++\nend\ngt(a, 3):\n    a++\nend\nlt(a, b):\n    a++\nend\n",
+			"SETREG V0 1 ; V0 = a\n" +
+				"SETREG V1 2 ; V1 = b\n" +
+				"BNERR V0 V1 ; V0 = a, V1 = b\n" +
+				"Jump eq.1.end\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"eq.1.end:\n" +
+				"BEQRR V0 V1 ; V0 = a, V1 = b\n" +
+				"Jump neq.2.end\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"neq.2.end:\n" +
+				"SETREG VC 3\n" +
+				"SUB VC V0 ; V0 = a\n" +
+				"BNE VF 0\n" +
+				"Jump gt.3.end\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"gt.3.end:\n" +
+				"RegCpy V0 VC ; V0 = a\n" +
+				"SUB VC V1 ; V1 = b\n" +
+				"BNE VF 0\n" +
+				"Jump lt.4.end\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"lt.4.end:\n",
+		},
+		{
+			"whileLoop",
+			"Uint32 a = 0\nwhile(a < 3):\n    a++\nend\nwhileNot(a, 0):\n    a--\nend\n",
+			"SETREG V0 0 ; V0 = a\n" +
+				"while.1:\n" +
+				"SETREG VC 3\n" +
+				"SUBN VC V0 ; V0 = a\n" +
+				"BNE VF 0\n" +
+				"Jump while.1.end\n" +
+				"ADD V0 1 ; V0 = a\n" +
+				"Jump while.1\n" +
+				"while.1.end:\n" +
+				"while.2:\n" +
+				"BEQ V0 0 ; V0 = a\n" +
+				"Jump while.2.end\n" +
+				"SETREG V1 1\n" +
+				"SUB V0 V1 ; V0 = a\n" +
+				"SETREG V1 0\n" +
+				"Jump while.2\n" +
+				"while.2.end:\n",
+		},
+	}
+
+	for _, tc := range programs {
+		if dump := generate(tc.program).Dump(); dump != tc.expected {
+			T.Logf("\nTestDump | %s generated:\n%s\nexpected:\n%s", tc.name, dump, tc.expected)
+			T.Fail()
+		}
+	}
+}
+
+func TestParseText(T *testing.T) {
+	source := `
+; count V0 up to 10
+        SETREG V0 0
+loop:   ADD V0 1            ; V0 = counter
+        BNE V0 10
+        Jump loop
+        FNJMP routine
+end:    Jump end
+routine:
+        MOV I 0xCA0
+        RET
+`
+	g, err := ParseText("TESTING", source)
+	if err != nil {
+		T.Fatal(err)
+	}
+
+	expected := "SETREG V0 0\n" +
+		"loop:\n" +
+		"ADD V0 1\n" +
+		"BNE V0 10\n" +
+		"Jump loop\n" +
+		"FNJMP routine\n" +
+		"end:\n" +
+		"Jump end\n" +
+		"routine:\n" +
+		"MOV I 0xCA0\n" +
+		"RET\n"
+	if dump := g.Dump(); dump != expected {
+		T.Logf("\nTestParseText | parsed:\n%s\nexpected:\n%s", dump, expected)
+		T.Fail()
+	}
+}
+
+func TestParseTextRoundTrip(T *testing.T) {
+	g := generate("def f(a):\n    Uint32 b = 1\n    b--\nend\nUint32 x = 3\nf(x)\nplot(x, 4)\n")
+	parsed, err := ParseText("TESTING", g.Dump())
+	if err != nil {
+		T.Fatal(err)
+	}
+
+	if len(parsed.Ir) != len(g.Ir) {
+		T.Fatalf("\nTestParseTextRoundTrip | parsed %d instructions. expected %d", len(parsed.Ir), len(g.Ir))
+	}
+	for i := range g.Ir {
+		if parsed.Ir[i].String() != g.Ir[i].String() {
+			T.Logf("\nTestParseTextRoundTrip | instruction %d parsed as %s. expected %s", i, parsed.Ir[i], g.Ir[i])
+			T.Fail()
+		}
+	}
+}
+
+func TestParseTextErrors(T *testing.T) {
+	sources := []string{
+		"Jump nowhere",
+		"SETREG V0",
+		"SETREG VG 1",
+		"ADD V0 ten",
+		"NOP",
+		"a:\na:\nRET",
+	}
+
+	for _, source := range sources {
+		if _, err := ParseText("TESTING", source); err == nil {
+			T.Logf("\nTestParseTextErrors | expected an error for %q", source)
+			T.Fail()
+		}
+	}
+}
+
+func TestInlineAsm(T *testing.T) {
+	program := "Uint32 score = 123\n" +
+		"asm:\n" +
+		"    LD I, 0x300\n" +
+		"    LD B, score ; smol variables resolve to their register\n" +
+		"    RND V1, 0x0F\n" +
+		"loop: JP loop\n" +
+		"end\n"
+	//the jump to loop is relative to the label of the block
+	expected := "SETREG V0 123 ; V0 = score\n" +
+		"asm.1:\n" +
+		"RAW 0xA300\n" +
+		"RAW 0xF033\n" +
+		"RAW 0xC10F\n" +
+		"RAW 0x1006 asm.1\n"
+
+	if dump := generate(program).Dump(); dump != expected {
+		T.Logf("\nTestInlineAsm | got\n%s\nexpected\n%s", dump, expected)
+		T.Fail()
+	}
+
+	g, err := ParseText("TESTING", expected)
+	if err != nil || g.Dump() != "SETREG V0 123\nasm.1:\nRAW 0xA300\nRAW 0xF033\nRAW 0xC10F\nRAW 0x1006 asm.1\n" {
+		T.Logf("\nTestInlineAsm | RAW does not round trip through the text form. %v", err)
+		T.Fail()
+	}
+}
+
+func TestLines(T *testing.T) {
+	g := generate("def f(a):\n    Uint32 b = 1\nend\n\nf(1)\nplot(c, 1)\n")
+
+	//Jump f.end, f:, SETREG, RET, f.end:, the argument, FNJMP and the start of the plot before it fails
+	expected := []int{1, 1, 2, 1, 1, 5, 5, 6, 6}
+	if len(g.Ir) != len(expected) {
+		T.Logf("\nTestLines | expected %d instructions. got\n%s", len(expected), g.Dump())
+		T.FailNow()
+	}
+	for i, line := range expected {
+		span, ok := g.LineOf(i)
+		if !ok || span.Start.Line != line {
+			T.Logf("\nTestLines | expected instruction %d to come from line %d. got %v", i, line, span)
+			T.Fail()
+		}
+	}
+
+	diagnostics := *g.Diagnostics
+	if len(diagnostics) != 1 || diagnostics[0].Pos.Line != 6 || diagnostics[0].Pos.Col != 6 || diagnostics[0].Span.End.Col != 7 {
+		T.Logf("\nTestLines | expected the undefined c to be reported at 6:6. got %v", diagnostics)
+		T.Fail()
+	}
+}
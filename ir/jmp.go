@@ -1,15 +1,20 @@
 package ir
 
+import "fmt"
+
 /*
 JMP FROM TO
 
 has an ID field because sometimes we need to reference to it
 for stuff like function hops where we need manipulate the call later
 to set the jump to address
+
+when Label is set the jump goes to that label and To is ignored
 */
 type Jump struct {
-	To int
-	ID string
+	To    int
+	ID    string
+	Label string
 }
 
 func (j Jump) GetInstructionName() string {
@@ -24,6 +29,17 @@ func (j Jump) usesVariableSpace() bool {
 	return false
 }
 
+func (j Jump) String() string {
+	if j.Label != "" {
+		return "Jump " + j.Label
+	}
+	return fmt.Sprintf("Jump 0x%03X", j.To)
+}
+
 func (g *Generator) newJumpInstructionFromLoose(to int) Jump {
-	return Jump{to, "0"}
+	return Jump{To: to, ID: "0"}
+}
+
+func (g *Generator) newJumpToLabel(label string) Jump {
+	return Jump{ID: "0", Label: label}
 }
@@ -0,0 +1,33 @@
+package ir
+
+/*
+LABEL NAME
+
+marks the address of the instruction after it so
+Jump, FNJMP and RAW can refer to it by name.
+The bytecode generator resolves labels once the whole ROM is laid out,
+so the IR never has to guess how many bytes an instruction takes
+*/
+type Label struct {
+	Name string
+}
+
+func (l Label) GetInstructionName() string {
+	return "LABEL"
+}
+
+func (l Label) Opcodeable() bool {
+	return false
+}
+
+func (l Label) usesVariableSpace() bool {
+	return false
+}
+
+func (l Label) String() string {
+	return l.Name + ":"
+}
+
+func (g *Generator) newLabel(name string) Label {
+	return Label{name}
+}
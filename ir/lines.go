@@ -0,0 +1,57 @@
+package ir
+
+import (
+	"sort"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
+)
+
+/*
+Line is an entry of the debug line table.
+The instructions from Instruction up to the one of the next entry
+were generated from the source at Span
+*/
+type Line struct {
+	Instruction int
+	Span        diag.Span
+}
+
+//enter marks node as the node the instructions that follow are generated from
+func (g *Generator) enter(node ast.Node) {
+	g.generating = append(g.generating, node)
+	g.mark(node.GetSpan())
+}
+
+//leave goes back to the node around the one that was entered last, like a function after its body
+func (g *Generator) leave() {
+	g.generating = g.generating[:len(g.generating)-1]
+	if len(g.generating) > 0 {
+		g.mark(g.generating[len(g.generating)-1].GetSpan())
+	}
+}
+
+//mark starts a new entry in the line table unless span has no position
+func (g *Generator) mark(span diag.Span) {
+	if span.Start.Line == 0 {
+		return
+	}
+	last := len(g.Lines) - 1
+	switch {
+	case last >= 0 && g.Lines[last].Instruction == len(g.Ir):
+		//nothing was generated for the previous entry
+		g.Lines[last].Span = span
+	case last >= 0 && g.Lines[last].Span == span:
+	default:
+		g.Lines = append(g.Lines, Line{Instruction: len(g.Ir), Span: span})
+	}
+}
+
+//LineOf returns the source the instruction at index was generated from
+func (g *Generator) LineOf(index int) (diag.Span, bool) {
+	i := sort.Search(len(g.Lines), func(i int) bool { return g.Lines[i].Instruction > index }) - 1
+	if i < 0 || index >= len(g.Ir) {
+		return diag.Span{}, false
+	}
+	return g.Lines[i].Span, true
+}
@@ -0,0 +1,32 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/fabulousduck/smol/ast"
+)
+
+/*
+createWhileLoopInstructions lays a loop out as
+
+	while.N:
+		Jump while.N.end unless the condition holds
+		body
+		Jump while.N
+	while.N.end:
+
+loops in the body get labels of their own, so loops can be nested
+*/
+func (g *Generator) createWhileLoopInstructions(loop *ast.WhileLoop) {
+	g.loopCount++
+	startLabel := fmt.Sprintf("while.%d", g.loopCount)
+	endLabel := startLabel + ".end"
+
+	g.Ir = append(g.Ir, g.newLabel(startLabel))
+	g.jumpUnless(loop.Condition, endLabel)
+
+	g.Generate(loop.Body)
+
+	g.Ir = append(g.Ir, g.newJumpToLabel(startLabel))
+	g.Ir = append(g.Ir, g.newLabel(endLabel))
+}
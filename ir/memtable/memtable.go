@@ -1,11 +1,5 @@
 package memtable
 
-import (
-	"os"
-
-	"github.com/fabulousduck/smol/errors"
-)
-
 /*
 MemTable is a simple collection of memory regions in use
 */
@@ -25,16 +19,21 @@ notes
 
 chip-8's blocks are 8 bit, so 1 byte.
 with a total of 4096 bytes
+
+Returns nil when there is no memory left for the variable
 */
 func (table MemTable) Put(name string, value int, size int) *MemRegion {
 	region := new(MemRegion)
 	//check if there is any memory left for our variable
 	currentMemSize := table.getSize()
 	if currentMemSize >= 95 {
-		errors.OutOfMemoryError()
+		return nil
 	}
 
 	region.Addr = table.FindNextEmptyAddr()
+	if region.Addr == -1 {
+		return nil
+	}
 	region.Size = 1
 	region.Value = value
 	table[name] = region
@@ -52,19 +51,18 @@ func IsValidMemRegion(regionAddr int) bool {
 
 /*
 LookupVariable looks up if a variable has been defined on the memory table
-internal lookups are silent since sometimes we need to do a lookup if an
+Returns nil if it has not, the caller decides if that is an error
+since sometimes we need to do a lookup if an
 internal variable that is not user defined has been set
 */
-func (table *MemTable) LookupVariable(name string, internalLookup bool) *MemRegion {
+func (table *MemTable) LookupVariable(name string) *MemRegion {
 	if val, ok := (*table)[name]; ok {
 		return val
 	}
-	if !internalLookup {
-		errors.UndefinedVariableError(name)
-	}
 	return nil
 }
 
+//FindNextEmptyAddr returns the first free address of variable space. -1 when it is full
 func (table MemTable) FindNextEmptyAddr() int {
 
 	varAddrSpaceStart := 0xEA0 - 0x200
@@ -76,7 +74,7 @@ func (table MemTable) FindNextEmptyAddr() int {
 		currentSpaceUsed++
 	}
 	if varAddrSpaceStart+currentSpaceUsed+0x2 > varAddrSpaceEnd {
-		errors.OutOfMemoryError()
+		return -1
 	}
 
 	return varAddrSpaceStart + currentSpaceUsed
@@ -89,20 +87,19 @@ func (table MemTable) getSize() int {
 /*
 Move moves a variable on the memory table.
 Mostly used for compression of variable space
+Returns false if there is no variable with that name
 */
-func (table *MemTable) Move(name string, to int, internal bool) {
+func (table *MemTable) Move(name string, to int) bool {
 	if val, ok := (*table)[name]; ok {
 		val.Addr = to
-		return
-	}
-
-	if !internal {
-		errors.UndefinedVariableError(name)
+		return true
 	}
+	return false
 }
 
 /*
 FindByAddr finds a given memory region by its addres instead of name
+Returns an empty string if nothing is stored at addr
 */
 func (table *MemTable) FindByAddr(addr int) string {
 	for k, v := range *table {
@@ -111,7 +108,5 @@ func (table *MemTable) FindByAddr(addr int) string {
 		}
 	}
 
-	errors.UnAssignedMemoryLookupError()
-	os.Exit(65)
 	return ""
 }
@@ -1,6 +1,8 @@
 package ir
 
 import (
+	"fmt"
+
 	"github.com/fabulousduck/smol/ast"
 	"github.com/fabulousduck/smol/errors"
 	"github.com/fabulousduck/smol/ir/memtable"
@@ -29,6 +31,17 @@ func (m MOV) usesVariableSpace() bool {
 	return true
 }
 
+/*
+String gives MOV I NNN for ANNN moves into the I register
+and MOV VX NN for moves into a general purpose register
+*/
+func (m MOV) String() string {
+	if m.ANNN {
+		return fmt.Sprintf("MOV I 0x%03X", m.R2)
+	}
+	return fmt.Sprintf("MOV V%X %d", m.R1, m.R2)
+}
+
 /*
 
 	newMovInstructionFromLoose takes a loose set of values and turns them into
@@ -41,7 +54,8 @@ func (m MOV) usesVariableSpace() bool {
 func (g *Generator) newMovInstructionFromLoose(R1 int, R2 int, ANNN bool) MOV {
 	instr := MOV{R1, R2, false}
 	if memtable.IsValidMemRegion(R1) {
-		errors.RegisterAdressModeFailure(R1)
+		//this is a bug in the compiler, not in the program
+		panic(errors.RegisterAdressModeFailure(R1))
 	}
 
 	instr.ANNN = ANNN
@@ -0,0 +1,195 @@
+package ir
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
+)
+
+/*
+ParseText reads IR in its canonical text form (see Dump) and returns
+a generator holding it, ready to be handed to the bytecode generator.
+
+Besides raw addresses, Jump and FNJMP accept labels.
+A label is defined by a name followed by a ':' either on its own line
+or in front of an instruction. Labels stay in the IR as LABEL instructions
+and are resolved by the bytecode generator once the ROM is laid out.
+
+Everything after a ';' or '#' on a line is a comment
+*/
+func ParseText(filename string, source string) (*Generator, error) {
+	g := NewGenerator(filename)
+	labels := map[string]bool{}
+	type labelUse struct {
+		line  int
+		label string
+	}
+	uses := []labelUse{}
+
+	for lineNumber, line := range strings.Split(source, "\n") {
+		lineNumber++
+		if i := strings.IndexAny(line, ";#"); i != -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+
+		//label definitions
+		for len(fields) > 0 && strings.HasSuffix(fields[0], ":") {
+			label := strings.TrimSuffix(fields[0], ":")
+			if labels[label] {
+				return nil, textError(filename, lineNumber, "label %s is defined twice", label)
+			}
+			labels[label] = true
+			g.Ir = append(g.Ir, Label{label})
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		instr, label, err := parseInstruction(fields)
+		if err != nil {
+			return nil, textError(filename, lineNumber, "%s", err.Error())
+		}
+		if label != "" {
+			uses = append(uses, labelUse{lineNumber, label})
+		}
+		g.Ir = append(g.Ir, instr)
+	}
+
+	for _, use := range uses {
+		if !labels[use.label] {
+			return nil, textError(filename, use.line, "undefined label %s", use.label)
+		}
+	}
+
+	return g, nil
+}
+
+//textError creates a diagnostic for a line of IR text
+func textError(filename string, line int, format string, args ...interface{}) diag.Diagnostic {
+	return errors.IRSyntaxError(fmt.Sprintf(format, args...)).At(diag.Pos{File: filename, Line: line})
+}
+
+/*
+parseInstruction parses a single instruction from its fields.
+If the instruction jumps to a label instead of an address
+the label is returned so it can be resolved later
+*/
+func parseInstruction(fields []string) (instruction, string, error) {
+	name, operands := fields[0], fields[1:]
+	expected := map[string]int{
+		"SETREG": 2, "SETMEM": 2, "RegCpy": 2, "ADD": 2, "ADDRR": 2, "SUB": 2, "SUBN": 2, "BNE": 2, "BEQ": 2, "BNERR": 2, "BEQRR": 2,
+		"PLOT": 3, "MOV": 2, "Jump": 1, "FNJMP": 1, "RET": 0, "RGD": 2, "RAW": 1,
+	}
+	count, ok := expected[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown instruction %s", name)
+	}
+	//RAW takes an optional label its address is relative to
+	if name == "RAW" && len(operands) == 2 {
+		count = 2
+	}
+	if len(operands) != count {
+		return nil, "", fmt.Errorf("%s takes %d operands. got %d", name, count, len(operands))
+	}
+
+	p := operandParser{}
+	var instr instruction
+	label := ""
+
+	switch name {
+	case "SETREG":
+		instr = SETREG{Index: p.register(operands[0]), Val: p.number(operands[1])}
+	case "SETMEM":
+		instr = SETMEM{Addr: p.number(operands[0]), Val: p.number(operands[1])}
+	case "RegCpy":
+		instr = RegCpy{From: p.register(operands[0]), To: p.register(operands[1])}
+	case "ADD":
+		instr = ADD{Register: p.register(operands[0]), Value: p.number(operands[1])}
+	case "ADDRR":
+		instr = ADDRR{Register: p.register(operands[0]), AmountRegister: p.register(operands[1])}
+	case "SUB":
+		instr = SUB{TargetRegister: p.register(operands[0]), AmountRegister: p.register(operands[1])}
+	case "SUBN":
+		instr = SUBN{TargetRegister: p.register(operands[0]), AmountRegister: p.register(operands[1])}
+	case "BNE":
+		instr = BNE{Lhs: p.register(operands[0]), Rhs: p.number(operands[1])}
+	case "BEQ":
+		instr = BEQ{Lhs: p.register(operands[0]), Rhs: p.number(operands[1])}
+	case "BNERR":
+		instr = BNERR{Lhs: p.register(operands[0]), Rhs: p.register(operands[1])}
+	case "BEQRR":
+		instr = BEQRR{Lhs: p.register(operands[0]), Rhs: p.register(operands[1])}
+	case "PLOT":
+		instr = PLOT{X: p.register(operands[0]), Y: p.register(operands[1]), H: p.number(operands[2])}
+	case "MOV":
+		if operands[0] == "I" {
+			instr = MOV{R1: 0xF, R2: p.number(operands[1]), ANNN: true}
+		} else {
+			instr = MOV{R1: p.register(operands[0]), R2: p.number(operands[1])}
+		}
+	case "Jump", "FNJMP":
+		addr := 0
+		if isNumber(operands[0]) {
+			addr = p.number(operands[0])
+		} else {
+			label = operands[0]
+		}
+		if name == "Jump" {
+			instr = Jump{To: addr, ID: "0", Label: label}
+		} else {
+			instr = FNJMP{Addr: addr, Label: label}
+		}
+	case "RET":
+		instr = RET{}
+	case "RGD":
+		instr = RGD{StartLocation: p.number(operands[0]), Count: p.number(operands[1])}
+	case "RAW":
+		instr = RAW{Opcode: p.number(operands[0]) & 0xFFFF}
+		if len(operands) == 2 {
+			label = operands[1]
+			instr = RAW{Opcode: p.number(operands[0]) & 0xFFFF, Label: label}
+		}
+	}
+
+	if p.err != nil {
+		return nil, "", p.err
+	}
+	return instr, label, nil
+}
+
+//operandParser keeps the first error it runs into so operands can be parsed without checking every one
+type operandParser struct {
+	err error
+}
+
+//register parses a register operand written as V0 through VF
+func (p *operandParser) register(operand string) int {
+	if len(operand) == 2 && (operand[0] == 'V' || operand[0] == 'v') {
+		if value, err := strconv.ParseUint(operand[1:], 16, 4); err == nil {
+			return int(value)
+		}
+	}
+	if p.err == nil {
+		p.err = fmt.Errorf("expected a register (V0 through VF). got %s", operand)
+	}
+	return 0
+}
+
+//number parses a decimal or 0x prefixed hexadecimal number
+func (p *operandParser) number(operand string) int {
+	value, err := strconv.ParseInt(operand, 0, 32)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("expected a number. got %s", operand)
+	}
+	return int(value)
+}
+
+func isNumber(operand string) bool {
+	_, err := strconv.ParseInt(operand, 0, 32)
+	return err == nil
+}
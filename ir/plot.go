@@ -1,11 +1,10 @@
 package ir
 
 import (
-	"os"
+	"fmt"
 	"strconv"
 
 	"github.com/fabulousduck/smol/ast"
-	"github.com/fabulousduck/smol/errors"
 )
 
 /*
@@ -29,6 +28,10 @@ func (p PLOT) usesVariableSpace() bool {
 	return false
 }
 
+func (p PLOT) String() string {
+	return fmt.Sprintf("PLOT V%X V%X %d", p.X, p.Y, p.H)
+}
+
 func (g *Generator) newPlotInstructionSet(plotStatement *ast.PlotStatement) PLOT {
 	/*
 		chip-8's pixel placement system works on a 8x8 sprite.
@@ -54,10 +57,10 @@ func (g *Generator) newPlotInstructionSet(plotStatement *ast.PlotStatement) PLOT
 	/*
 		check if the single pixel has been set or not
 	*/
-	if g.memTable.LookupVariable(topLeftPixelMemoryName, true) == nil {
+	if g.memTable.LookupVariable(topLeftPixelMemoryName) == nil {
 		g.Ir = append(g.Ir, g.newSetMemoryLocationFromLoose(topLeftPixelMemoryName, topLeftPixel))
 	}
-	pixelBufferVariable := g.memTable.LookupVariable(topLeftPixelMemoryName, true)
+	pixelBufferVariable := g.memTable.LookupVariable(topLeftPixelMemoryName)
 
 	/*
 		fill the I register with the memory address of the single pixel value
@@ -81,17 +84,10 @@ func (g *Generator) newPlotInstructionSet(plotStatement *ast.PlotStatement) PLOT
 		otherwise, we simply use the integer value of the plot statement
 	*/
 	if ast.NodeIsVariable(plotStatement.X) {
-		variableName := plotStatement.X.(*ast.StatVar).Value
-		//first we check if the variable is loaded into a register somewhere
-		registerLoadedValue := g.regTable.Find(variableName)
-		if registerLoadedValue == -1 {
-			//if it is not in any register. the variable does not exist and we error out
-			errors.UndefinedVariableError(variableName)
-			os.Exit(65)
-		} else {
-			//if it is variable loaded
-			g.Ir = append(g.Ir, g.newRegCpy(registerLoadedValue, g.plotXRegister))
-		}
+		variable := plotStatement.X.(*ast.StatVar)
+		//the variable has to be loaded into a register somewhere. if it is not, it does not exist
+		registerLoadedValue := g.findVariable(variable)
+		g.Ir = append(g.Ir, g.newRegCpy(registerLoadedValue, g.plotXRegister))
 	} else {
 		variableValue := plotStatement.X.(*ast.NumLit).Value
 		intValue, _ := strconv.Atoi(variableValue)
@@ -99,18 +95,11 @@ func (g *Generator) newPlotInstructionSet(plotStatement *ast.PlotStatement) PLOT
 	}
 
 	if ast.NodeIsVariable(plotStatement.Y) {
-		variableName := plotStatement.Y.(*ast.StatVar).Value
-
-		//first we check if the variable is loaded into a register somewhere
-		registerLoadedValue := g.regTable.Find(variableName)
-		if registerLoadedValue == -1 {
-			//if it is not in any register. the variable does not exist and we error out
-			errors.UndefinedVariableError(variableName)
-			os.Exit(65)
-		} else {
-			//if it is variable loaded
-			g.Ir = append(g.Ir, g.newRegCpy(registerLoadedValue, g.plotYRegister))
-		}
+		variable := plotStatement.Y.(*ast.StatVar)
+
+		//the variable has to be loaded into a register somewhere. if it is not, it does not exist
+		registerLoadedValue := g.findVariable(variable)
+		g.Ir = append(g.Ir, g.newRegCpy(registerLoadedValue, g.plotYRegister))
 	} else {
 		variableValue := plotStatement.Y.(*ast.NumLit).Value
 		intValue, _ := strconv.Atoi(variableValue)
@@ -0,0 +1,87 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/fabulousduck/smol/asm"
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
+)
+
+/*
+RAW OPCODE
+
+a chip-8 opcode that goes into the ROM as is.
+These come from inline asm blocks and cover the opcodes
+smol has no syntax for such as FX33 (BCD) and CXNN (random)
+
+when Label is set the NNN part of the opcode is an offset from that label.
+This is how jumps inside an asm block end up at the right address
+*/
+type RAW struct {
+	Opcode int
+	Label  string
+}
+
+func (r RAW) GetInstructionName() string {
+	return "RAW"
+}
+
+func (r RAW) Opcodeable() bool {
+	return true
+}
+
+func (r RAW) usesVariableSpace() bool {
+	return false
+}
+
+func (r RAW) String() string {
+	if r.Label != "" {
+		return fmt.Sprintf("RAW 0x%04X %s", r.Opcode, r.Label)
+	}
+	return fmt.Sprintf("RAW 0x%04X", r.Opcode)
+}
+
+/*
+createAsmBlockInstructions assembles an inline asm block.
+The block is assembled as if it starts at address 0 and gets a label of its own,
+instructions that point at labels in the block become RAW instructions relative to it.
+smol variable names can be used where a register is expected
+*/
+func (g *Generator) createAsmBlockInstructions(block *ast.AsmBlock) {
+	g.asmBlockCount++
+	label := fmt.Sprintf("asm.%d", g.asmBlockCount)
+
+	assembler := asm.NewAssembler(g.filename)
+	assembler.Origin = 0
+	assembler.FirstLine = block.Line
+	assembler.ResolveRegister = func(name string) (int, bool) {
+		register := g.lookupVariable(name)
+		return register, register != -1
+	}
+
+	code, err := assembler.Assemble(block.Source)
+	if err != nil {
+		//the assembler already knows the line the error is on
+		d := err.(diag.Diagnostic)
+		g.fail(errors.InlineAssemblyError(d.Message).At(d.Pos))
+	}
+	if len(code)%2 != 0 {
+		g.fail(errors.InlineAssemblyError("asm block has an odd amount of bytes").At(diag.Pos{File: g.filename, Line: block.Line}))
+	}
+
+	relocations := map[int]bool{}
+	for _, offset := range assembler.Relocations {
+		relocations[offset] = true
+	}
+
+	g.Ir = append(g.Ir, g.newLabel(label))
+	for i := 0; i < len(code); i += 2 {
+		instr := RAW{Opcode: int(code[i])<<8 | int(code[i+1])}
+		if relocations[i] {
+			instr.Label = label
+		}
+		g.Ir = append(g.Ir, instr)
+	}
+}
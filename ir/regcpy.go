@@ -1,5 +1,7 @@
 package ir
 
+import "fmt"
+
 type RegCpy struct {
 	From, To int
 }
@@ -16,6 +18,10 @@ func (j RegCpy) usesVariableSpace() bool {
 	return false
 }
 
+func (j RegCpy) String() string {
+	return fmt.Sprintf("RegCpy V%X V%X", j.From, j.To)
+}
+
 /*
 newRegCpy: from R1 into R2
 */
@@ -1,8 +1,6 @@
 package registertable
 
 import (
-	"os"
-
 	"github.com/fabulousduck/smol/errors"
 )
 
@@ -49,15 +47,40 @@ func (table RegisterTable) Init() {
 	}
 }
 
+/*
+FindEmptyRegister returns the lowest register that is not reserved and not in use
+Returns -1 if every register is in use
+*/
 func (table RegisterTable) FindEmptyRegister() int {
-	for k, v := range table {
+	for k := 0; k < len(table); k++ {
+		v := table[k]
 		if v.Value == 0 && v.Name == "" && isNonReservedRegister(k) {
 			return k
 		}
 	}
-	errors.OutOfRegistersError()
-	os.Exit(65)
-	return 0
+	return -1
+}
+
+//GeneralPurposeCount returns how many registers variables can be stored in
+func GeneralPurposeCount() int {
+	count := 0
+	for i := 0; i < 0x10; i++ {
+		if isNonReservedRegister(i) {
+			count++
+		}
+	}
+	return count
+}
+
+//Names returns the names of the values in the general purpose registers, lowest register first
+func (table RegisterTable) Names() []string {
+	names := []string{}
+	for i := 0; i < len(table); i++ {
+		if isNonReservedRegister(i) && table[i].Name != "" {
+			names = append(names, table[i].Name)
+		}
+	}
+	return names
 }
 
 func isNonReservedRegister(registerIndex int) bool {
@@ -66,17 +89,18 @@ func isNonReservedRegister(registerIndex int) bool {
 
 /*
 PutRegisterValue set the value of register to value
+An invalid register is a bug in the compiler, not in the program, so it panics
 */
 func (table RegisterTable) PutRegisterValue(register int, value int, name string) {
 	if !isValidRegisterIndex(register) {
-		errors.IlligalRegisterAccess(register)
+		panic(errors.IlligalRegisterAccess(register))
 	}
 
 	table[register] = Register{value, name}
 }
 
 func isValidRegisterIndex(registerIndex int) bool {
-	return registerIndex < 0xF
+	return registerIndex >= 0 && registerIndex < 0xF
 }
 
 /*
@@ -23,6 +23,10 @@ func (r RET) usesVariableSpace() bool {
 	return false
 }
 
+func (r RET) String() string {
+	return "RET"
+}
+
 func (g *Generator) newRetInstruction() RET {
 	return RET{}
 }
@@ -1,5 +1,12 @@
 package ir
 
+import (
+	"fmt"
+
+	"github.com/fabulousduck/smol/errors"
+	"github.com/fabulousduck/smol/ir/registertable"
+)
+
 /*
 RGD 0 X
 
@@ -10,7 +17,7 @@ This is used when we need to do a temporary scope change for
 functions
 */
 type RGD struct {
-	startLocation, count int
+	StartLocation, Count int
 }
 
 func (r RGD) GetInstructionName() string {
@@ -25,6 +32,10 @@ func (r RGD) usesVariableSpace() bool {
 	return false
 }
 
+func (r RGD) String() string {
+	return fmt.Sprintf("RGD 0x%03X %d", r.StartLocation, r.Count)
+}
+
 /*
 NewRGDInstruction creates a new RGD instruction
 
@@ -32,19 +43,24 @@ uses the I register
 */
 func (g *Generator) NewRGDInstruction(endRegister int) RGD {
 	instr := RGD{}
-	instr.count = endRegister
+	instr.Count = endRegister
 
 	//find a region for the dump to go into
 	emptyRegionStart := g.memTable.FindNextEmptyAddr()
-	instr.startLocation = emptyRegionStart
+	if emptyRegionStart == -1 {
+		g.fail(errors.OutOfMemoryError())
+	}
+	instr.StartLocation = emptyRegionStart
 
-	//set I register to the start of the dump region
-	g.regTable.PutRegisterValue(g.IRegisterIndex, emptyRegionStart, "I_REGISTER")
-	g.Ir = append(g.Ir, g.newSpecificRegisterSet(g.IRegisterIndex, emptyRegionStart, "I_REGISTER"))
+	//point I at the start of the dump region. FX55 stores the registers at I
+	g.regTable[g.IRegisterIndex] = registertable.Register{Value: emptyRegionStart, Name: "I_REGISTER"}
+	g.Ir = append(g.Ir, g.newMovInstructionFromLoose(g.IRegisterIndex, emptyRegionStart, true))
 
 	//put all register values into a slice
 	for i := 0; i < endRegister; i++ {
-		g.memTable.Put(g.regTable[i].Name, g.regTable[i].Value, 1)
+		if g.memTable.Put(g.regTable[i].Name, g.regTable[i].Value, 1) == nil {
+			g.fail(errors.OutOfMemoryError())
+		}
 	}
 
 	return instr
@@ -5,6 +5,7 @@ package ir
 */
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/fabulousduck/smol/ast"
@@ -28,6 +29,10 @@ func (s SETMEM) usesVariableSpace() bool {
 	return true
 }
 
+func (s SETMEM) String() string {
+	return fmt.Sprintf("SETMEM 0x%03X %d", s.Addr, s.Val)
+}
+
 /*
 SET Val ADDR
 
@@ -49,9 +54,16 @@ func (s SETREG) usesVariableSpace() bool {
 	return true
 }
 
+func (s SETREG) String() string {
+	return fmt.Sprintf("SETREG V%X %d", s.Index, s.Val)
+}
+
 func (g *Generator) newSetMemoryLocationFromLoose(name string, value int) SETMEM {
 	instr := SETMEM{}
 	region := g.memTable.Put(name, value, 1)
+	if region == nil {
+		g.fail(errors.OutOfMemoryError())
+	}
 	instr.Addr = region.Addr
 	instr.Val = value
 	return instr
@@ -78,11 +90,11 @@ func (g *Generator) newSetRegisterInstruction(v *ast.Variable, varValue int, res
 		if val, ok := g.memTable[resolutionName]; ok {
 			varValue = val.Value
 		} else {
-			errors.UndefinedVariableError(resolutionName)
+			g.failAt(errors.UndefinedVariableError(resolutionName), v.Value)
 		}
 	}
 
-	emptyRegisterAddress := g.regTable.FindEmptyRegister()
+	emptyRegisterAddress := g.findEmptyRegister()
 	g.regTable.PutRegisterValue(emptyRegisterAddress, varValue, v.Name)
 	instr.Index = emptyRegisterAddress
 	instr.Val = varValue
@@ -92,7 +104,7 @@ func (g *Generator) newSetRegisterInstruction(v *ast.Variable, varValue int, res
 func (g *Generator) newSetRegisterInstructionFromLoose(registerName string, varValue int) SETREG {
 	instr := SETREG{}
 
-	emptyRegisterAddress := g.regTable.FindEmptyRegister()
+	emptyRegisterAddress := g.findEmptyRegister()
 	g.regTable.PutRegisterValue(emptyRegisterAddress, varValue, registerName)
 	instr.Index = emptyRegisterAddress
 	instr.Val = varValue
@@ -100,15 +112,22 @@ func (g *Generator) newSetRegisterInstructionFromLoose(registerName string, varV
 }
 
 func (g *Generator) createVariableOperationInstructions(variable *ast.Variable) {
+	//expressions and strings cannot be placed in a register yet
+	if variable.Value == nil || variable.Value.GetNodeName() == "stringLit" {
+		g.fail(errors.UnsupportedOperationError("assignment of " + variable.Type + " " + variable.Name).
+			WithHelp("only numbers, booleans and other variables can be assigned in a ROM for now. -interpret runs the program as is"))
+	}
+
 	//check if its a reference
 	if ast.NodeIsVariable(variable.Value) {
 		//if it is a reference, we get the original value,
 		//and copy it over into a new register with the name of the new variable
 		variableValue := variable.Value.(*ast.StatVar)
-		emptyRegister := g.regTable.FindEmptyRegister()
-		originalRegister := g.regTable.Find(variableValue.Value)
+		emptyRegister := g.findEmptyRegister()
+		originalRegister := g.findVariable(variableValue)
 		g.regTable[emptyRegister] = registertable.Register{g.regTable[originalRegister].Value, variable.Name}
 		g.Ir = append(g.Ir, g.newRegCpy(originalRegister, emptyRegister))
+		g.variableRegisters[g.scoped(variable.Name)] = emptyRegister
 	} else if variable.Value.GetNodeName() == "boolLit" {
 		variableValue := variable.Value.(*ast.BoolLit).Value
 		booleanIntegerRepresentation := 0
@@ -117,10 +136,14 @@ func (g *Generator) createVariableOperationInstructions(variable *ast.Variable)
 		} else {
 			booleanIntegerRepresentation = 0
 		}
-		g.Ir = append(g.Ir, g.newSetRegisterInstructionFromLoose(variable.Name, booleanIntegerRepresentation))
+		instr := g.newSetRegisterInstructionFromLoose(variable.Name, booleanIntegerRepresentation)
+		g.Ir = append(g.Ir, instr)
+		g.variableRegisters[g.scoped(variable.Name)] = instr.Index
 	} else {
 		variableValue, _ := strconv.Atoi(variable.Value.(*ast.NumLit).Value)
-		g.Ir = append(g.Ir, g.newSetRegisterInstructionFromLoose(variable.Name, variableValue))
+		instr := g.newSetRegisterInstructionFromLoose(variable.Name, variableValue)
+		g.Ir = append(g.Ir, instr)
+		g.variableRegisters[g.scoped(variable.Name)] = instr.Index
 	}
 }
 
@@ -128,12 +151,12 @@ func (g *Generator) createSetStatement(instruction *ast.SetStatement) {
 	castVariable := instruction.MHS.(*ast.StatVar)
 
 	//find the register in which the variable is currently stored
-	variableRegister := g.regTable.Find(castVariable.Value)
+	variableRegister := g.findVariable(castVariable)
 
 	//if the rhs of the set statement is a variable too, we need to get its value first
 	//and then embed a register copy instruction
 	if ast.NodeIsVariable(instruction.RHS) {
-		referenceVariableRegister := g.regTable.Find(instruction.RHS.(*ast.StatVar).Value)
+		referenceVariableRegister := g.findVariable(instruction.RHS.(*ast.StatVar))
 		g.Ir = append(g.Ir, g.newRegCpy(referenceVariableRegister, variableRegister))
 	} else {
 		//otherwise, we need to set the value of the register to the right hand side value
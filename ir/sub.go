@@ -1,5 +1,7 @@
 package ir
 
+import "fmt"
+
 /*
 SUB instruction
 
@@ -24,6 +26,39 @@ func (s SUB) usesVariableSpace() bool {
 	return false
 }
 
+func (s SUB) String() string {
+	return fmt.Sprintf("SUB V%X V%X", s.TargetRegister, s.AmountRegister)
+}
+
+/*
+SUBN instruction. the reverse of SUB
+
+opcode: 8XY7
+X: register that is set to Y minus X
+Y: register X is deducted from
+
+VF is set to 1 when Y is at least X, so nothing had to be borrowed
+*/
+type SUBN struct {
+	TargetRegister, AmountRegister int
+}
+
+func (s SUBN) GetInstructionName() string {
+	return "SUBN"
+}
+
+func (s SUBN) Opcodeable() bool {
+	return true
+}
+
+func (s SUBN) usesVariableSpace() bool {
+	return false
+}
+
+func (s SUBN) String() string {
+	return fmt.Sprintf("SUBN V%X V%X", s.TargetRegister, s.AmountRegister)
+}
+
 /*
 Sub is a little more complicated than ADD since there is no opcode to increment
 a register with a negative value.
@@ -35,14 +70,14 @@ func (g *Generator) newSubInstruction(targetVariableTableIndex int, amount int)
 
 	//First step is to check if there is a register free
 	amountRegisterName := "amountRegister"
-	amountRegister := g.regTable.FindEmptyRegister()
+	amountRegister := g.findEmptyRegister()
 	subInstruction := SUB{targetVariableTableIndex, amountRegister}
 
 	//modify the internal register table so it keeps track of things
 	g.regTable.PutRegisterValue(amountRegister, amount, amountRegisterName)
 
 	//create the instruction for the amount register setting
-	g.Ir = append(g.Ir, g.newSpecificRegisterSet(targetVariableTableIndex, amount, amountRegisterName))
+	g.Ir = append(g.Ir, g.newSpecificRegisterSet(amountRegister, amount, amountRegisterName))
 
 	//create the actual subtract instruction
 	g.Ir = append(g.Ir, subInstruction)
@@ -2,25 +2,35 @@ package lexer
 
 import (
 	"bytes"
-	"fmt"
-	"os"
-
-	"github.com/davecgh/go-spew/spew"
+	"strings"
 
+	"github.com/fabulousduck/smol/diag"
 	"github.com/fabulousduck/smol/errors"
 )
 
-//Token contains all info about a specific token from syntax
+/*
+Token contains all info about a specific token from syntax.
+When the lexer keeps trivia Raw holds the token as it was written,
+Leading holds the whitespace, comments and skipped symbols before it
+and Trailing those after it up to the end of its line
+*/
 type Token struct {
-	Value, Type string
-	Line, Col   int
+	Value, Type            string
+	Line, Col              int
+	Leading, Raw, Trailing string
 }
 
 //Lexer contains all the info needed for the lexer to generate a set of usable tokens
+//problems in the program are added to Diagnostics
+//KeepComments turns comments into comment tokens instead of skipping them, the parser does not accept those
+//KeepTrivia attaches everything that is not a token to the tokens around it so the source can be rebuilt from them
 type Lexer struct {
 	Tokens                                []Token
+	Diagnostics                           *diag.List
+	KeepComments, KeepTrivia              bool
 	currentIndex, currentLine, currentCol int
 	FileName, Program                     string
+	offsets                               [][2]int
 }
 
 //NewLexer creates a new instance of a lexer stuct
@@ -28,6 +38,7 @@ func NewLexer(filename string, program string) *Lexer {
 	l := new(Lexer)
 	l.Program = program
 	l.FileName = filename
+	l.Diagnostics = new(diag.List)
 	l.currentIndex = 0
 	l.currentLine = 1
 	return l
@@ -46,17 +57,24 @@ func newToken(line int, col int, value string) *Token {
 func (l *Lexer) Lex() {
 
 	for l.currentIndex < len(l.Program) {
+		start := l.currentIndex
 		currTok := newToken(l.currentLine, l.currentCol, l.currentChar())
 		switch currTok.Type {
 		case "character":
 			currTok.Value = l.peekTypesN([]string{"integer", "character"})
+			if currTok.Value == "asm" && l.nextNonBlank() == ":" {
+				l.add(*currTok, start, l.currentIndex)
+				l.lexAsmBlock()
+				continue
+			}
 		case "integer":
 			currTok.Value = l.peekTypesN([]string{"integer"})
 		case "comment":
-			l.readComment()
-			l.advance()
-			l.currentCol = 0
-			continue
+			//the newline that ends the comment is left for the newline case so the line is counted
+			currTok.Value = l.readComment()
+			if !l.KeepComments {
+				continue
+			}
 		case "double_quote":
 			l.advance()
 			currTok.Value = l.peekUntil([]string{"double_quote"})
@@ -68,6 +86,20 @@ func (l *Lexer) Lex() {
 				l.advance()
 			}
 			l.advance()
+		case "less_than":
+			if l.peek() == "=" {
+				currTok.Value = "<="
+				currTok.Type = "less_than_or_equal"
+				l.advance()
+			}
+			l.advance()
+		case "greater_than":
+			if l.peek() == "=" {
+				currTok.Value = ">="
+				currTok.Type = "greater_than_or_equal"
+				l.advance()
+			}
+			l.advance()
 		case "plus":
 			if l.peek() == "+" {
 				currTok.Value = "++"
@@ -90,10 +122,6 @@ func (l *Lexer) Lex() {
 			fallthrough
 		case "division":
 			fallthrough
-		case "less_than":
-			fallthrough
-		case "greater_than":
-			fallthrough
 		case "comma":
 			fallthrough
 		case "left_bracket":
@@ -110,24 +138,87 @@ func (l *Lexer) Lex() {
 			fallthrough
 		case "semicolon":
 			l.advance()
+		case "exclamation":
+			//! is only a symbol in !=
+			if l.peek() == "=" {
+				currTok.Value = "!="
+				currTok.Type = "not_equal"
+				l.advance()
+				l.advance()
+				break
+			}
+			fallthrough
 		case "undefined_symbol":
-			errors.Report(l.currentLine, l.FileName, fmt.Sprintf("undefined symbol \"%s\" used", currTok.Value))
-			os.Exit(65)
+			//the symbol is skipped so the rest of the program still gets checked
+			start := l.pos()
+			end := start
+			end.Col++
+			l.Diagnostics.Add(errors.UndefinedSymbolError(currTok.Value).Between(start, end))
+			l.advance()
+			continue
 		case "newline":
+			//the \r of a \r\n line ending does not start a line of its own
+			if l.currentChar() == "\r" && l.peek() == "\n" {
+				l.advance()
+				continue
+			}
+			l.advance()
 			l.currentCol = 0
 			l.currentLine++
-			l.advance()
 			continue
 		case "ignoreable":
-			l.currentCol = 0
 			l.advance()
 			continue
 		}
 
-		l.Tokens = append(l.Tokens, *currTok)
+		l.add(*currTok, start, l.currentIndex)
 
 	}
 	l.tagKeywords()
+	if l.KeepTrivia {
+		l.attachTrivia()
+	}
+}
+
+//add appends a token that was read from Program[start:end]
+func (l *Lexer) add(token Token, start int, end int) {
+	l.Tokens = append(l.Tokens, token)
+	l.offsets = append(l.offsets, [2]int{start, end})
+}
+
+/*
+attachTrivia fills in the trivia of every token.
+Whatever is between two tokens up to the end of the line belongs to the token before it,
+the rest belongs to the token after it. The end_of_file token holds what is after the last line
+
+	Uint32 a = 1 #one\n#two\nprint(a)
+	             ^^^^^ trailing of 1
+	                  ^^^^^^^^ leading of print
+*/
+func (l *Lexer) attachTrivia() {
+	l.add(Token{Type: "end_of_file", Line: l.currentLine, Col: l.currentCol}, len(l.Program), len(l.Program))
+
+	previous := 0
+	for i := range l.Tokens {
+		start, end := l.offsets[i][0], l.offsets[i][1]
+		gap := l.Program[previous:start]
+		if i > 0 {
+			trailing := gap
+			if newline := strings.IndexAny(gap, "\r\n"); newline != -1 {
+				trailing = gap[:newline]
+			}
+			l.Tokens[i-1].Trailing = trailing
+			gap = gap[len(trailing):]
+		}
+		l.Tokens[i].Leading = gap
+		l.Tokens[i].Raw = l.Program[start:end]
+		previous = end
+	}
+}
+
+//pos gives the position of the current character
+func (l *Lexer) pos() diag.Pos {
+	return diag.Pos{File: l.FileName, Line: l.currentLine, Col: l.currentCol + 1}
 }
 
 func (l *Lexer) advance() {
@@ -135,15 +226,84 @@ func (l *Lexer) advance() {
 	l.currentIndex++
 }
 
-func (l *Lexer) readComment() {
-	l.currentIndex++
-	for t := determineType(l.currentChar()); t != "newline"; t = determineType(l.currentChar()) {
-		l.currentIndex++
+/*
+lexAsmBlock reads the body of an inline assembly block.
+The body is not tokenized since chip-8 assembly has its own syntax.
+Everything up to the line that only holds "end" becomes a single asm_body token
+followed by the close_block token
+
+	asm:
+	    LD B, score
+	end
+*/
+func (l *Lexer) lexAsmBlock() {
+	for l.currentChar() != ":" {
+		l.advance()
 	}
+	l.add(*newToken(l.currentLine, l.currentCol, ":"), l.currentIndex, l.currentIndex+1)
+	l.advance()
+
+	//the body starts on the line of the asm keyword so line numbers in it can be offset easily
+	body := newToken(l.currentLine, l.currentCol, "")
+	body.Type = "asm_body"
+	bodyStart := l.currentIndex
+	var lines []string
+	for l.currentIndex < len(l.Program) {
+		end := strings.IndexAny(l.Program[l.currentIndex:], "\n")
+		if end == -1 {
+			end = len(l.Program) - l.currentIndex
+		}
+		line := l.Program[l.currentIndex : l.currentIndex+end]
+
+		if strings.TrimSpace(line) == "end" {
+			//the newline before the line with end is not part of the body
+			bodyEnd := l.currentIndex - 1
+			if bodyEnd < bodyStart {
+				bodyEnd = bodyStart
+			}
+			body.Value = strings.Join(lines, "\n")
+			l.add(*body, bodyStart, bodyEnd)
+			l.currentCol = strings.Index(line, "end")
+			l.add(Token{Value: "end", Type: "close_block", Line: l.currentLine, Col: l.currentCol}, l.currentIndex+l.currentCol, l.currentIndex+l.currentCol+3)
+			l.currentIndex += end
+			return
+		}
+
+		lines = append(lines, line)
+		l.currentIndex += end
+		if l.currentIndex < len(l.Program) {
+			l.currentIndex++
+			l.currentLine++
+		}
+		l.currentCol = 0
+	}
+
+	//an unterminated block still gets its body so the parser can report the missing end
+	body.Value = strings.Join(lines, "\n")
+	l.add(*body, bodyStart, l.currentIndex)
+}
+
+//nextNonBlank returns the first character from the current one that is not a space or tab
+func (l *Lexer) nextNonBlank() string {
+	for i := l.currentIndex; i < len(l.Program); i++ {
+		if l.Program[i] != ' ' && l.Program[i] != '\t' {
+			return string(l.Program[i])
+		}
+	}
+	return ""
+}
+
+//readComment reads a comment up to the end of its line and returns it with its #
+func (l *Lexer) readComment() string {
+	start := l.currentIndex
+	for l.currentIndex < len(l.Program) && determineType(l.currentChar()) != "newline" {
+		l.advance()
+	}
+	return l.Program[start:l.currentIndex]
 }
 
 func (l *Lexer) peek() string {
-	if l.currentIndex+1 <= len(l.Program) {
+	if l.currentIndex+1 < len(l.Program) {
 		return string(l.Program[l.currentIndex+1])
 	}
 	return ""
@@ -158,7 +318,6 @@ func (l *Lexer) peekUntil(types []string) string {
 
 		//we do this to avoid index out of range errors
 		if l.currentIndex+1 >= len(l.Program) {
-			spew.Dump("out of range clause")
 			currentString.WriteString(char)
 			l.advance()
 
@@ -197,21 +356,19 @@ func (l *Lexer) currentChar() string {
 	return string(l.Program[l.currentIndex])
 }
 
+//contextualKeywords are only keywords when a '(' follows them, so programs can still use them as names
+var contextualKeywords = []string{"eq_block", "neq_block", "gt_block", "lt_block"}
+
 func (l *Lexer) tagKeywords() {
 	for i, token := range l.Tokens {
-		if token.Type == "character" {
-			l.Tokens[i].Type = getKeyword(&token)
+		if token.Type != "character" {
+			continue
 		}
-	}
-}
 
-//ThrowSemanticError can be used when an error occurs while generating an AST and not at interpret time
-func ThrowSemanticError(token *Token, expected []string, filename string) {
-	errors.Report(
-		token.Line,
-		filename,
-		fmt.Sprintf("expected one of [%s]. got %s",
-			errors.ConcatVariables(expected, ", "),
-			token.Type),
-	)
+		keyword := getKeyword(&token)
+		if contains(keyword, contextualKeywords) && (i+1 >= len(l.Tokens) || l.Tokens[i+1].Type != "left_parenthesis") {
+			keyword = "string"
+		}
+		l.Tokens[i].Type = keyword
+	}
 }
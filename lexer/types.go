@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -14,7 +15,7 @@ type OperatorAttributes struct {
 
 //IsLitteral checks if a given token is a litteral type
 func IsLitteral(token Token) bool {
-	litteralTypes := []string{"character", "string", "integer", "string_litteral"}
+	litteralTypes := []string{"character", "string", "integer", "string_litteral", "boolean_keyword"}
 
 	for _, litteral := range litteralTypes {
 		if token.Type == litteral {
@@ -42,6 +43,7 @@ func determineType(character string) string {
 		"star":              []string{"*"},
 		"division":          []string{"/"},
 		"equals":            []string{"="},
+		"exclamation":       []string{"!"},
 		"dash":              []string{"-"},
 		"left_bracket":      []string{"["},
 		"right_bracket":     []string{"]"},
@@ -79,22 +81,42 @@ func containsEither(names []string, list []string) bool {
 	return false
 }
 
-func getKeyword(token *Token) string {
-	keywords := map[string][]string{
-		"function_definition": []string{"def"},
-		"boolean_keyword":     []string{"True", "False"},
-		"variable_type":       []string{"String", "Bool", "Uint32", "Uint64"},
-		"print":               []string{"print"},
-		"close_block":         []string{"end"},
-		"set_variable":        []string{"set"},
-		"if_statement":        []string{"if"},
-		"switch":              []string{"switch"},
-		"case":                []string{"case"},
-		"end_of_switch":       []string{"default"},
-		"free":                []string{"free"},
-		"plot":                []string{"plot"},
+//keywords holds the words that are tagged with a type of their own, by type
+var keywords = map[string][]string{
+	"function_definition": []string{"def"},
+	"boolean_keyword":     []string{"True", "False"},
+	"variable_type":       []string{"String", "Bool", "Uint32", "Uint64"},
+	"print":               []string{"print"},
+	"close_block":         []string{"end"},
+	"set_variable":        []string{"set"},
+	"if_statement":        []string{"if"},
+	"elif_statement":      []string{"elif"},
+	"else_statement":      []string{"else"},
+	"while_loop":          []string{"while"},
+	"while_not_loop":      []string{"whileNot"},
+	"eq_block":            []string{"eq"},
+	"neq_block":           []string{"neq"},
+	"gt_block":            []string{"gt"},
+	"lt_block":            []string{"lt"},
+	"switch":              []string{"switch"},
+	"case":                []string{"case"},
+	"end_of_switch":       []string{"default"},
+	"free":                []string{"free"},
+	"plot":                []string{"plot"},
+	"asm_block":           []string{"asm"},
+}
+
+//Keywords returns every keyword of the language in alphabetical order
+func Keywords() []string {
+	words := []string{}
+	for _, values := range keywords {
+		words = append(words, values...)
 	}
+	sort.Strings(words)
+	return words
+}
 
+func getKeyword(token *Token) string {
 	for key, values := range keywords {
 		if contains(token.Value, values) {
 			return key
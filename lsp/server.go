@@ -0,0 +1,272 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
+	"github.com/fabulousduck/smol/ir"
+	"github.com/fabulousduck/smol/lexer"
+)
+
+/*
+Server is a language server for smol that speaks LSP over a pair of streams.
+Every time a document is opened or changed it is run through the lexer,
+parser and IR generator. The diagnostics of those stages are published
+and the tokens are kept to answer definition, hover and completion requests
+*/
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+//document is an open file and what was found out about it the last time it was checked
+type document struct {
+	index       *index
+	generator   *ir.Generator
+	diagnostics diag.List
+}
+
+//NewServer creates a server that reads requests from in and writes responses to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := new(Server)
+	s.in = bufio.NewReader(in)
+	s.out = out
+	s.documents = make(map[string]*document)
+	return s
+}
+
+/*
+Run serves requests until the client sends exit or closes its stream.
+returns nil when the client asked for a shutdown before exiting
+*/
+func (s *Server) Run() error {
+	for {
+		msg, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				s.respondError(nil, codeParseError, err.Error())
+				continue
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit before shutdown")
+			}
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+//handle answers a single request or notification
+func (s *Server) handle(msg *message) error {
+	switch msg.Method {
+	case "initialize":
+		return s.respond(msg.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1,
+				"definitionProvider": true,
+				"hoverProvider":      true,
+				"completionProvider": map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{"name": "smol"},
+		})
+	case "initialized":
+		return nil
+	case "shutdown":
+		s.shutdown = true
+		return s.respond(msg.ID, nil)
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil
+		}
+		return s.check(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		//the server asks for full documents so the last change holds all of the text
+		return s.check(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	case "textDocument/definition":
+		return s.positionRequest(msg, s.definition)
+	case "textDocument/hover":
+		return s.positionRequest(msg, s.hover)
+	case "textDocument/completion":
+		return s.positionRequest(msg, s.completion)
+	}
+
+	//notifications that are not known are ignored, requests get an error
+	if msg.ID == nil {
+		return nil
+	}
+	return s.respondError(msg.ID, codeMethodNotFound, "method not found: "+msg.Method)
+}
+
+//positionRequest decodes the parameters of a request about a place in a document and answers it
+func (s *Server) positionRequest(msg *message, answer func(*document, string, Position) interface{}) error {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return s.respondError(msg.ID, codeInvalidParams, err.Error())
+	}
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return s.respond(msg.ID, nil)
+	}
+	return s.respond(msg.ID, answer(doc, params.TextDocument.URI, params.Position))
+}
+
+func (s *Server) definition(doc *document, uri string, pos Position) interface{} {
+	sym, ok := doc.index.definition(pos)
+	if !ok {
+		return nil
+	}
+	return Location{URI: uri, Range: tokenRange(sym.Token)}
+}
+
+func (s *Server) hover(doc *document, uri string, pos Position) interface{} {
+	sym, ok := doc.index.definition(pos)
+	if !ok {
+		return nil
+	}
+	text := "```smol\n" + sym.describe() + "\n```"
+	if register, ok := doc.generator.VariableRegister(sym.scopedName()); ok && sym.Kind != "function" {
+		text += fmt.Sprintf("\n\nCHIP-8 register: `V%X`", register)
+	}
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: text},
+		Range:    tokenRange(doc.index.tokens[doc.index.tokenAt(pos)]),
+	}
+}
+
+func (s *Server) completion(doc *document, uri string, pos Position) interface{} {
+	items := []CompletionItem{}
+	for _, keyword := range lexer.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+	for _, sym := range doc.index.completions(pos) {
+		item := CompletionItem{Label: sym.Name, Kind: completionVariable, Detail: sym.describe()}
+		if sym.Kind == "function" {
+			item.Kind = completionFunction
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+//check runs a document through the compiler and publishes what it found
+func (s *Server) check(uri string, text string) error {
+	doc := analyze(filenameOf(uri), text)
+	s.documents[uri] = doc
+
+	diagnostics := []Diagnostic{}
+	for _, d := range doc.diagnostics {
+		diagnostics = append(diagnostics, toProtocol(d))
+	}
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+/*
+analyze runs the lexer, parser and IR generator over text.
+The stages recover from the errors they report, a crash in one of them
+is reported as an internal error so the server keeps running
+*/
+func analyze(filename string, text string) (doc *document) {
+	diagnostics := new(diag.List)
+	doc = &document{generator: ir.NewGenerator(filename)}
+
+	l := lexer.NewLexer(filename, text)
+	l.Diagnostics = diagnostics
+	defer func() {
+		if r := recover(); r != nil {
+			diagnostics.Add(errors.CompilerCrashError(r).InFile(filename))
+		}
+		doc.index = newIndex(l.Tokens)
+		doc.diagnostics = *diagnostics
+	}()
+	l.Lex()
+
+	p := ast.NewParser(filename, l.Tokens)
+	p.Diagnostics = diagnostics
+	p.Ast, _ = p.Parse("")
+
+	doc.generator.Diagnostics = diagnostics
+	doc.generator.Generate(p.Ast)
+	return doc
+}
+
+//filenameOf turns a file uri into the path diagnostics are reported in
+func filenameOf(uri string) string {
+	return strings.TrimPrefix(uri, "file://")
+}
+
+//toProtocol converts a diagnostic. diagnostics without a place are put at the start of the document
+func toProtocol(d diag.Diagnostic) Diagnostic {
+	start := Position{}
+	if d.Pos.Line > 0 {
+		start = Position{Line: d.Pos.Line - 1, Character: max(d.Pos.Col-1, 0)}
+	}
+	end := start
+	end.Character++
+	if d.Span.End.Line > 0 && d.Span.End.Col > 0 {
+		end = Position{Line: d.Span.End.Line - 1, Character: d.Span.End.Col - 1}
+	}
+	if !before(start, end) {
+		end = Position{Line: start.Line, Character: start.Character + 1}
+	}
+
+	severity := severityError
+	switch d.Severity {
+	case diag.Warning:
+		severity = severityWarning
+	case diag.Note:
+		severity = severityInformation
+	}
+
+	message := d.Message
+	for _, note := range d.Notes {
+		message += "\n" + note
+	}
+	return Diagnostic{Range: Range{Start: start, End: end}, Severity: severity, Code: d.Code, Source: "smol", Message: message}
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}) error {
+	return writeMessage(s.out, map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (s *Server) respondError(id *json.RawMessage, code int, text string) error {
+	return writeMessage(s.out, map[string]interface{}{"jsonrpc": "2.0", "id": id, "error": responseError{Code: code, Message: text}})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
@@ -0,0 +1,127 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const program = `Uint32 a = 1
+def add(x, y):
+    print(x)
+end
+add(a, 2)
+plot(a, b)
+`
+
+//script holds the messages an editor would send, in order
+type script struct {
+	bytes.Buffer
+	nextID int
+}
+
+func (s *script) request(method string, params interface{}) {
+	s.nextID++
+	writeMessage(s, map[string]interface{}{"jsonrpc": "2.0", "id": s.nextID, "method": method, "params": params})
+}
+
+func (s *script) notify(method string, params interface{}) {
+	writeMessage(s, map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *script) at(method string, line int, character int) {
+	s.request(method, textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: "file:///test.lo"}, Position: Position{Line: line, Character: character}})
+}
+
+func TestSession(T *testing.T) {
+	s := new(script)
+	s.request("initialize", map[string]interface{}{})
+	s.notify("initialized", map[string]interface{}{})
+	s.notify("textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: "file:///test.lo", Text: program}})
+	s.at("textDocument/definition", 4, 4)
+	s.at("textDocument/definition", 2, 10)
+	s.at("textDocument/hover", 4, 4)
+	s.at("textDocument/completion", 2, 4)
+	s.request("textDocument/formatting", map[string]interface{}{})
+	s.request("shutdown", nil)
+	s.notify("exit", nil)
+
+	var out bytes.Buffer
+	if err := NewServer(s, &out).Run(); err != nil {
+		T.Logf("\nTestSession | expected a clean exit. got %s", err)
+		T.Fail()
+	}
+
+	var replies []*message
+	r := bufio.NewReader(&out)
+	for msg, err := readMessage(r); err == nil; msg, err = readMessage(r) {
+		replies = append(replies, msg)
+	}
+	if len(replies) != 8 {
+		T.Logf("\nTestSession | expected 8 messages from the server. got %d", len(replies))
+		T.FailNow()
+	}
+
+	expect(T, "initialize", string(replies[0].Result), `"definitionProvider":true`, `"hoverProvider":true`, `"completionProvider"`)
+
+	var published PublishDiagnosticsParams
+	json.Unmarshal(replies[1].Params, &published)
+	if replies[1].Method != "textDocument/publishDiagnostics" || len(published.Diagnostics) != 1 || published.Diagnostics[0].Range.Start != (Position{Line: 5, Character: 8}) {
+		T.Logf("\nTestSession | expected the undefined b on line 6 to be published. got %s", replies[1].Params)
+		T.Fail()
+	}
+
+	expect(T, "definition of a variable", string(replies[2].Result), `"start":{"line":0,"character":7}`)
+	expect(T, "definition of a parameter", string(replies[3].Result), `"start":{"line":1,"character":8}`)
+	expect(T, "hover", string(replies[4].Result), "Uint32 a", "CHIP-8 register: `V0`")
+	expect(T, "completion", string(replies[5].Result), `"label":"def","kind":14`, `"label":"x","kind":6`, `"label":"add","kind":3`, `"label":"a","kind":6`)
+
+	if replies[6].Error == nil || replies[6].Error.Code != codeMethodNotFound {
+		T.Logf("\nTestSession | expected an unknown method to be answered with an error. got %s", replies[6].Result)
+		T.Fail()
+	}
+	if string(replies[7].Result) != "null" || replies[7].Error != nil {
+		T.Logf("\nTestSession | expected shutdown to be answered with null. got %s", replies[7].Result)
+		T.Fail()
+	}
+}
+
+func TestCompletionScope(T *testing.T) {
+	idx := newIndex(analyze("test.lo", program).index.tokens)
+	for _, sym := range idx.completions(Position{Line: 5, Character: 0}) {
+		if sym.Kind == "parameter" {
+			T.Logf("\nTestCompletionScope | expected the parameters of add to be out of scope after its end. got %s", sym.Name)
+			T.Fail()
+		}
+	}
+}
+
+func expect(T *testing.T, name string, got string, want ...string) {
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			T.Logf("\nTestSession | expected the %s reply to contain %s. got %s", name, w, got)
+			T.Fail()
+		}
+	}
+}
+
+func TestHoverScope(T *testing.T) {
+	doc := analyze("test.lo", "Uint32 a = 1\ndef add(x):\n    Uint32 a = 2\n    x++\nend\nadd(a)\n")
+	tests := []struct {
+		pos      Position
+		register string
+	}{
+		{Position{Line: 0, Character: 7}, "V0"},
+		{Position{Line: 1, Character: 8}, "V1"},
+		{Position{Line: 2, Character: 11}, "V2"},
+	}
+	for _, test := range tests {
+		hover, ok := new(Server).hover(doc, "file:///test.lo", test.pos).(Hover)
+		if !ok || !strings.Contains(hover.Contents.Value, "`"+test.register+"`") {
+			T.Logf("\nTestHoverScope | expected the hover at %+v to show %s. got %+v", test.pos, test.register, hover)
+			T.Fail()
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package lsp
+
+import (
+	"strings"
+
+	"github.com/fabulousduck/smol/lexer"
+)
+
+/*
+symbol is a name declared in a program.
+Kind is one of variable, function or parameter.
+Type is the type a variable was declared with.
+function is the function whose body a variable or parameter is declared in
+*/
+type symbol struct {
+	Name, Kind, Type string
+	Params           []string
+	Token            lexer.Token
+	block            int
+	function         string
+}
+
+/*
+index holds every declaration in a program and the blocks they are visible in.
+It is built from tokens rather than the AST so it still works
+for programs the parser gave up on halfway through.
+
+blocks maps a block to the block it is in, the program itself is block 0.
+blockAfter holds the block every token leaves the program in
+*/
+type index struct {
+	tokens     []lexer.Token
+	symbols    []symbol
+	blocks     []int
+	blockAfter []int
+}
+
+/*
+newIndex walks the tokens of a program and records its declarations.
+
+	Uint32 a = 1       a variable
+	def add(x, y):     a function and the parameters of its body
+	end
+
+A colon that ends its line, or an asm block, opens a block and end closes it
+*/
+func newIndex(tokens []lexer.Token) *index {
+	idx := new(index)
+	idx.tokens = tokens
+	idx.blocks = []int{-1}
+	idx.blockAfter = make([]int, len(tokens))
+
+	current := 0
+	var params []symbol
+	//functions holds the function every block is part of, defining is the def whose body opens next
+	functions := []string{""}
+	defining := ""
+	for i, token := range tokens {
+		switch token.Type {
+		case "variable_type":
+			if name, ok := idx.nameAt(i + 1); ok {
+				idx.symbols = append(idx.symbols, symbol{Name: name.Value, Kind: "variable", Type: token.Value, Token: name, block: current, function: functions[current]})
+			}
+		case "function_definition":
+			name, ok := idx.nameAt(i + 1)
+			if !ok {
+				break
+			}
+			function := symbol{Name: name.Value, Kind: "function", Token: name, block: current}
+			params = nil
+			defining = name.Value
+			for j := i + 3; j < len(tokens) && tokens[j].Type != "right_parenthesis" && tokens[j].Line == name.Line; j++ {
+				if param, ok := idx.nameAt(j); ok {
+					function.Params = append(function.Params, param.Value)
+					params = append(params, symbol{Name: param.Value, Kind: "parameter", Token: param, function: name.Value})
+				}
+			}
+			idx.symbols = append(idx.symbols, function)
+		case "double_dot":
+			if !idx.opensBlock(i) {
+				break
+			}
+			idx.blocks = append(idx.blocks, current)
+			current = len(idx.blocks) - 1
+			if defining == "" {
+				defining = functions[idx.blocks[current]]
+			}
+			functions = append(functions, defining)
+			defining = ""
+			//parameters belong to the body of the function they were declared by
+			for _, param := range params {
+				param.block = current
+				idx.symbols = append(idx.symbols, param)
+			}
+			params = nil
+		case "close_block", "else_statement", "elif_statement":
+			//else and elif close the body of the if, their colon opens a block of their own
+			if current != 0 {
+				current = idx.blocks[current]
+			}
+		}
+		idx.blockAfter[i] = current
+	}
+	return idx
+}
+
+//nameAt returns the token at i when it is a name
+func (idx *index) nameAt(i int) (lexer.Token, bool) {
+	if i >= len(idx.tokens) {
+		return lexer.Token{}, false
+	}
+	token := idx.tokens[i]
+	return token, isName(token)
+}
+
+//opensBlock reports whether the colon at i starts a block
+func (idx *index) opensBlock(i int) bool {
+	if i+1 >= len(idx.tokens) {
+		return true
+	}
+	next := idx.tokens[i+1]
+	return next.Type == "asm_body" || next.Line != idx.tokens[i].Line
+}
+
+func isName(token lexer.Token) bool {
+	return token.Type == "character" || token.Type == "string"
+}
+
+//tokenAt returns the index of the name under pos or -1 when there is none
+func (idx *index) tokenAt(pos Position) int {
+	for i, token := range idx.tokens {
+		if !isName(token) || token.Line-1 != pos.Line {
+			continue
+		}
+		if pos.Character >= token.Col && pos.Character <= token.Col+len(token.Value) {
+			return i
+		}
+	}
+	return -1
+}
+
+//blockAt returns the block pos is in
+func (idx *index) blockAt(pos Position) int {
+	block := 0
+	for i, token := range idx.tokens {
+		if !before(tokenRange(token).Start, pos) {
+			break
+		}
+		block = idx.blockAfter[i]
+	}
+	return block
+}
+
+//visible returns the symbols that can be used in block, the innermost ones first
+func (idx *index) visible(block int) []symbol {
+	var symbols []symbol
+	for ; block != -1; block = idx.blocks[block] {
+		for _, s := range idx.symbols {
+			if s.block == block {
+				symbols = append(symbols, s)
+			}
+		}
+	}
+	return symbols
+}
+
+/*
+definition returns the declaration the name under pos refers to.
+The innermost declaration wins, within a block the last one before the name does.
+functions can be called before they are declared
+*/
+func (idx *index) definition(pos Position) (symbol, bool) {
+	i := idx.tokenAt(pos)
+	if i == -1 {
+		return symbol{}, false
+	}
+	name := idx.tokens[i]
+	at := tokenRange(name).Start
+
+	//a declaration is its own definition
+	for _, s := range idx.symbols {
+		if s.Token == name {
+			return s, true
+		}
+	}
+
+	var found *symbol
+	symbols := idx.visible(idx.blockAt(at))
+	for j := range symbols {
+		s := &symbols[j]
+		if s.Name != name.Value {
+			continue
+		}
+		if found != nil && found.block != s.block {
+			break
+		}
+		declared := !before(at, tokenRange(s.Token).Start)
+		if found == nil || declared {
+			found = s
+		}
+	}
+	if found == nil {
+		return symbol{}, false
+	}
+	return *found, true
+}
+
+//completions returns the names that can be used at pos
+func (idx *index) completions(pos Position) []symbol {
+	var symbols []symbol
+	seen := make(map[string]bool)
+	for _, s := range idx.visible(idx.blockAt(pos)) {
+		if seen[s.Name] || (s.Kind == "variable" && !before(tokenRange(s.Token).Start, pos)) {
+			continue
+		}
+		seen[s.Name] = true
+		symbols = append(symbols, s)
+	}
+	return symbols
+}
+
+//describe gives the declaration of a symbol the way it is written
+func (s symbol) describe() string {
+	switch s.Kind {
+	case "function":
+		return "def " + s.Name + "(" + strings.Join(s.Params, ", ") + ")"
+	case "parameter":
+		return "parameter " + s.Name
+	}
+	return s.Type + " " + s.Name
+}
+
+//scopedName is the name the compiler gives a variable or parameter, function.name when it is part of a function
+func (s symbol) scopedName() string {
+	if s.function == "" {
+		return s.Name
+	}
+	return s.function + "." + s.Name
+}
+
+//tokenRange gives the range a token covers
+func tokenRange(token lexer.Token) Range {
+	start := Position{Line: token.Line - 1, Character: token.Col}
+	end := start
+	end.Character += len(token.Value)
+	return Range{Start: start, End: end}
+}
+
+//before reports whether a comes before b
+func before(a Position, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
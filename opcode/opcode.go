@@ -0,0 +1,205 @@
+package opcode
+
+/*
+Opcode is a single two byte chip-8 instruction.
+
+The constructors follow Cowgod's chip-8 technical reference.
+Operands are masked to the amount of bits they have in the opcode.
+nnn: 12 bit address
+kk: 8 bit value
+x, y: 4 bit register indexes
+n: 4 bit value
+*/
+type Opcode uint16
+
+//Bytes returns the opcode in the big endian order chip-8 reads it in
+func (o Opcode) Bytes() []byte {
+	return []byte{byte(o >> 8), byte(o)}
+}
+
+func addr(id int, nnn int) Opcode {
+	return Opcode(id<<12 | nnn&0xFFF)
+}
+
+func registerByte(id int, x int, kk int) Opcode {
+	return Opcode(id<<12 | (x&0xF)<<8 | kk&0xFF)
+}
+
+func registers(id int, x int, y int, n int) Opcode {
+	return Opcode(id<<12 | (x&0xF)<<8 | (y&0xF)<<4 | n&0xF)
+}
+
+//ClearScreen 00E0
+func ClearScreen() Opcode {
+	return 0x00E0
+}
+
+//Return 00EE returns from a subroutine
+func Return() Opcode {
+	return 0x00EE
+}
+
+//Sys 0NNN calls a machine code routine. ignored by modern interpreters
+func Sys(nnn int) Opcode {
+	return addr(0x0, nnn)
+}
+
+//Jump 1NNN jumps to nnn
+func Jump(nnn int) Opcode {
+	return addr(0x1, nnn)
+}
+
+//Call 2NNN calls the subroutine at nnn
+func Call(nnn int) Opcode {
+	return addr(0x2, nnn)
+}
+
+//SkipEqual 3XKK skips the next instruction if Vx == kk
+func SkipEqual(x int, kk int) Opcode {
+	return registerByte(0x3, x, kk)
+}
+
+//SkipNotEqual 4XKK skips the next instruction if Vx != kk
+func SkipNotEqual(x int, kk int) Opcode {
+	return registerByte(0x4, x, kk)
+}
+
+//SkipRegistersEqual 5XY0 skips the next instruction if Vx == Vy
+func SkipRegistersEqual(x int, y int) Opcode {
+	return registers(0x5, x, y, 0x0)
+}
+
+//Load 6XKK sets Vx to kk
+func Load(x int, kk int) Opcode {
+	return registerByte(0x6, x, kk)
+}
+
+//AddByte 7XKK adds kk onto Vx without setting VF
+func AddByte(x int, kk int) Opcode {
+	return registerByte(0x7, x, kk)
+}
+
+//LoadRegister 8XY0 sets Vx to Vy
+func LoadRegister(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x0)
+}
+
+//Or 8XY1 sets Vx to Vx | Vy
+func Or(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x1)
+}
+
+//And 8XY2 sets Vx to Vx & Vy
+func And(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x2)
+}
+
+//Xor 8XY3 sets Vx to Vx ^ Vy
+func Xor(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x3)
+}
+
+//AddRegister 8XY4 adds Vy onto Vx. VF is set to the carry
+func AddRegister(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x4)
+}
+
+//Sub 8XY5 subtracts Vy from Vx. VF is set when there is no borrow
+func Sub(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x5)
+}
+
+//ShiftRight 8XY6 shifts Vx right by one. VF is set to the bit shifted out
+func ShiftRight(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x6)
+}
+
+//SubN 8XY7 sets Vx to Vy - Vx. VF is set when there is no borrow
+func SubN(x int, y int) Opcode {
+	return registers(0x8, x, y, 0x7)
+}
+
+//ShiftLeft 8XYE shifts Vx left by one. VF is set to the bit shifted out
+func ShiftLeft(x int, y int) Opcode {
+	return registers(0x8, x, y, 0xE)
+}
+
+//SkipRegistersNotEqual 9XY0 skips the next instruction if Vx != Vy
+func SkipRegistersNotEqual(x int, y int) Opcode {
+	return registers(0x9, x, y, 0x0)
+}
+
+//LoadI ANNN sets I to nnn
+func LoadI(nnn int) Opcode {
+	return addr(0xA, nnn)
+}
+
+//JumpV0 BNNN jumps to nnn + V0
+func JumpV0(nnn int) Opcode {
+	return addr(0xB, nnn)
+}
+
+//Random CXKK sets Vx to a random byte & kk
+func Random(x int, kk int) Opcode {
+	return registerByte(0xC, x, kk)
+}
+
+//Draw DXYN draws an n byte sprite from I at Vx, Vy
+func Draw(x int, y int, n int) Opcode {
+	return registers(0xD, x, y, n)
+}
+
+//SkipKeyPressed EX9E skips the next instruction if the key in Vx is pressed
+func SkipKeyPressed(x int) Opcode {
+	return registerByte(0xE, x, 0x9E)
+}
+
+//SkipKeyNotPressed EXA1 skips the next instruction if the key in Vx is not pressed
+func SkipKeyNotPressed(x int) Opcode {
+	return registerByte(0xE, x, 0xA1)
+}
+
+//LoadDelay FX07 sets Vx to the delay timer
+func LoadDelay(x int) Opcode {
+	return registerByte(0xF, x, 0x07)
+}
+
+//WaitKey FX0A waits for a key press and stores the key in Vx
+func WaitKey(x int) Opcode {
+	return registerByte(0xF, x, 0x0A)
+}
+
+//SetDelay FX15 sets the delay timer to Vx
+func SetDelay(x int) Opcode {
+	return registerByte(0xF, x, 0x15)
+}
+
+//SetSound FX18 sets the sound timer to Vx
+func SetSound(x int) Opcode {
+	return registerByte(0xF, x, 0x18)
+}
+
+//AddI FX1E adds Vx onto I
+func AddI(x int) Opcode {
+	return registerByte(0xF, x, 0x1E)
+}
+
+//LoadFont FX29 points I at the font sprite for the hex digit in Vx
+func LoadFont(x int) Opcode {
+	return registerByte(0xF, x, 0x29)
+}
+
+//StoreBCD FX33 stores the hundreds, tens and ones of Vx at I, I+1 and I+2
+func StoreBCD(x int) Opcode {
+	return registerByte(0xF, x, 0x33)
+}
+
+//StoreRegisters FX55 stores V0 through Vx in memory starting at I
+func StoreRegisters(x int) Opcode {
+	return registerByte(0xF, x, 0x55)
+}
+
+//LoadRegisters FX65 loads V0 through Vx from memory starting at I
+func LoadRegisters(x int) Opcode {
+	return registerByte(0xF, x, 0x65)
+}
@@ -0,0 +1,75 @@
+package opcode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fabulousduck/smol/disasm"
+)
+
+func TestEncoders(T *testing.T) {
+	encoded := []struct {
+		opcode   Opcode
+		expected uint16
+		mnemonic string
+	}{
+		{ClearScreen(), 0x00E0, "CLS"},
+		{Return(), 0x00EE, "RET"},
+		{Sys(0x123), 0x0123, "SYS 0x123"},
+		{Jump(0x20C), 0x120C, "JP 0x20C"},
+		{Jump(0x00A), 0x100A, "JP 0x00A"},
+		{Call(0xABC), 0x2ABC, "CALL 0xABC"},
+		{SkipEqual(0x3, 0x0A), 0x330A, "SE V3, 0x0A"},
+		{SkipNotEqual(0xC, 0xFF), 0x4CFF, "SNE VC, 0xFF"},
+		{SkipRegistersEqual(0x1, 0x2), 0x5120, "SE V1, V2"},
+		{Load(0x3, 0x0A), 0x630A, "LD V3, 0x0A"},
+		{AddByte(0x0, 0x01), 0x7001, "ADD V0, 0x01"},
+		{LoadRegister(0xE, 0x0), 0x8E00, "LD VE, V0"},
+		{Or(0x1, 0x2), 0x8121, "OR V1, V2"},
+		{And(0x1, 0x2), 0x8122, "AND V1, V2"},
+		{Xor(0x1, 0x2), 0x8123, "XOR V1, V2"},
+		{AddRegister(0x1, 0x2), 0x8124, "ADD V1, V2"},
+		{Sub(0x1, 0x2), 0x8125, "SUB V1, V2"},
+		{ShiftRight(0x1, 0x2), 0x8126, "SHR V1, V2"},
+		{SubN(0x1, 0x2), 0x8127, "SUBN V1, V2"},
+		{ShiftLeft(0x1, 0x2), 0x812E, "SHL V1, V2"},
+		{SkipRegistersNotEqual(0x1, 0x2), 0x9120, "SNE V1, V2"},
+		{LoadI(0xEA0), 0xAEA0, "LD I, 0xEA0"},
+		{JumpV0(0x300), 0xB300, "JP V0, 0x300"},
+		{Random(0x5, 0x0F), 0xC50F, "RND V5, 0x0F"},
+		{Draw(0xE, 0xD, 1), 0xDED1, "DRW VE, VD, 1"},
+		{SkipKeyPressed(0x4), 0xE49E, "SKP V4"},
+		{SkipKeyNotPressed(0x4), 0xE4A1, "SKNP V4"},
+		{LoadDelay(0x2), 0xF207, "LD V2, DT"},
+		{WaitKey(0x2), 0xF20A, "LD V2, K"},
+		{SetDelay(0x2), 0xF215, "LD DT, V2"},
+		{SetSound(0x2), 0xF218, "LD ST, V2"},
+		{AddI(0x2), 0xF21E, "ADD I, V2"},
+		{LoadFont(0x2), 0xF229, "LD F, V2"},
+		{StoreBCD(0x2), 0xF233, "LD B, V2"},
+		{StoreRegisters(0x2), 0xF255, "LD [I], V2"},
+		{LoadRegisters(0x2), 0xF265, "LD V2, [I]"},
+	}
+
+	for _, e := range encoded {
+		if uint16(e.opcode) != e.expected {
+			T.Logf("\nTestEncoders | %s encoded as %04X. expected %04X", e.mnemonic, uint16(e.opcode), e.expected)
+			T.Fail()
+		}
+		if text, ok := disasm.Decode(uint16(e.opcode)); !ok || text != e.mnemonic {
+			T.Logf("\nTestEncoders | %04X decodes as %q. expected %q", e.expected, text, e.mnemonic)
+			T.Fail()
+		}
+	}
+}
+
+func TestOperandMasking(T *testing.T) {
+	if Jump(0x1234) != 0x1234&0x0FFF|0x1000 || Load(0x13, 0x1FF) != 0x63FF {
+		T.Logf("\nTestOperandMasking | operands leaked into the opcode identifier")
+		T.Fail()
+	}
+	if !bytes.Equal(Draw(0xE, 0xD, 1).Bytes(), []byte{0xDE, 0xD1}) {
+		T.Logf("\nTestOperandMasking | bytes are not big endian")
+		T.Fail()
+	}
+}
@@ -0,0 +1,187 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/interpreter"
+	"github.com/fabulousduck/smol/ir"
+	"github.com/fabulousduck/smol/lexer"
+)
+
+const (
+	prompt             = ">> "
+	continuationPrompt = ".. "
+	replFilename       = "repl"
+)
+
+/*
+Repl reads smol code line by line and runs it.
+Blocks that are opened with a ':' are buffered until their matching "end"
+
+Every entry is run by the interpreter and also fed to the IR generator
+so the register allocation and IR can be inspected as the session goes on.
+The interpreter runs more than a ROM can hold, so what the generator cannot compile is left out of the IR without an error
+*/
+type Repl struct {
+	Interpreter *interpreter.Interpreter
+	Generator   *ir.Generator
+	in          *bufio.Scanner
+	out         io.Writer
+	buffer      []string
+	depth       int
+}
+
+//NewRepl creates a new REPL session reading from in and writing to out
+func NewRepl(in io.Reader, out io.Writer) *Repl {
+	r := new(Repl)
+	r.in = bufio.NewScanner(in)
+	r.out = out
+	r.Interpreter = interpreter.NewInterpreter(replFilename)
+	r.Interpreter.Out = out
+	r.Generator = ir.NewGenerator(replFilename)
+	return r
+}
+
+//Start runs the read eval print loop until the input runs out or :quit is entered
+func (r *Repl) Start() {
+	fmt.Fprint(r.out, prompt)
+	for r.in.Scan() {
+		line := r.in.Text()
+
+		if len(r.buffer) == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if !r.metaCommand(strings.TrimSpace(line)) {
+				return
+			}
+			fmt.Fprint(r.out, prompt)
+			continue
+		}
+
+		r.feed(line)
+		if r.depth > 0 {
+			fmt.Fprint(r.out, continuationPrompt)
+			continue
+		}
+		fmt.Fprint(r.out, prompt)
+	}
+}
+
+/*
+feed adds a line to the buffer and runs the buffer once
+all blocks in it are closed
+*/
+func (r *Repl) feed(line string) {
+	r.buffer = append(r.buffer, line)
+
+	//the whole buffer is lexed so the body of an asm block is not mistaken for smol
+	l := lexer.NewLexer(replFilename, strings.Join(r.buffer, "\n"))
+	l.Lex()
+
+	r.depth = 0
+	for _, token := range l.Tokens {
+		switch token.Type {
+		case "double_dot":
+			r.depth++
+		case "close_block", "else_statement", "elif_statement":
+			//the colon after else and elif opens the block again
+			r.depth--
+		}
+	}
+
+	if r.depth > 0 {
+		return
+	}
+
+	r.eval(strings.Join(r.buffer, "\n"))
+	r.buffer = nil
+	r.depth = 0
+}
+
+//eval runs a complete piece of source against the state of the session
+func (r *Repl) eval(source string) {
+	l := lexer.NewLexer(replFilename, source)
+	l.Lex()
+	if len(l.Tokens) == 0 {
+		return
+	}
+
+	p := ast.NewParser(replFilename, l.Tokens)
+	p.Diagnostics = l.Diagnostics
+	nodes, _ := p.Parse("")
+	if r.report(*p.Diagnostics, source) {
+		return
+	}
+
+	r.Interpreter.HadError = false
+	r.Interpreter.Source = source
+	r.Interpreter.Run(nodes)
+	if r.Interpreter.HadError {
+		return
+	}
+
+	r.Generator.Diagnostics = new(diag.List)
+	r.Generator.Generate(nodes)
+}
+
+//report prints diagnostics in source to the session and returns whether there were errors
+func (r *Repl) report(diagnostics diag.List, source string) bool {
+	renderer := diag.NewRenderer(r.out)
+	renderer.Sources[replFilename] = source
+	renderer.Render(diagnostics)
+	return diagnostics.HasErrors()
+}
+
+/*
+metaCommand handles the commands starting with ':'
+returns false when the session should end
+*/
+func (r *Repl) metaCommand(command string) bool {
+	switch command {
+	case ":quit", ":q":
+		return false
+	case ":vars":
+		r.dumpVariables()
+	case ":regs":
+		r.dumpRegisters()
+	case ":ir":
+		r.dumpIR()
+	case ":help":
+		fmt.Fprintln(r.out, ":vars  show the variables in the global scope")
+		fmt.Fprintln(r.out, ":regs  show the register table")
+		fmt.Fprintln(r.out, ":ir    show the IR generated so far")
+		fmt.Fprintln(r.out, ":quit  exit the REPL")
+	default:
+		fmt.Fprintf(r.out, "unknown command %s. try :help\n", command)
+	}
+	return true
+}
+
+func (r *Repl) dumpVariables() {
+	names := []string{}
+	for name := range r.Interpreter.Global.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := r.Interpreter.Global.Variables[name]
+		fmt.Fprintf(r.out, "%s %s = %s\n", value.Type, name, value.String())
+	}
+}
+
+func (r *Repl) dumpRegisters() {
+	table := r.Generator.RegisterTable()
+	for i := 0; i < len(table); i++ {
+		fmt.Fprintf(r.out, "V%X %-16s %d\n", i, table[i].Name, table[i].Value)
+	}
+}
+
+//dumpIR prints the IR in the same text form as -emit=ir
+func (r *Repl) dumpIR() {
+	fmt.Fprint(r.out, r.Generator.Dump())
+}
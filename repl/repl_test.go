@@ -0,0 +1,47 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSession(T *testing.T) {
+	input := strings.Join([]string{
+		"Uint32 a = 10",
+		"def show(x):",
+		"    print(x)",
+		"end",
+		"a++",
+		"show(a)",
+		"Uint32 s = a + 2",
+		"print(s)",
+		":vars",
+		":regs",
+		":ir",
+		":quit",
+		"print(a)",
+	}, "\n")
+
+	var out bytes.Buffer
+	NewRepl(strings.NewReader(input), &out).Start()
+	output := out.String()
+
+	expected := []string{"11\n", "Uint32 a = 11\n", "13\n", "V0 a ", ">> SETREG V0 10 ; V0 = a\nJump show.end\n"}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			T.Logf("\nTestSession | expected output to contain %q. got:\n%s", e, output)
+			T.Fail()
+		}
+	}
+
+	if strings.Contains(output, "error") {
+		T.Logf("\nTestSession | expected no errors. got:\n%s", output)
+		T.Fail()
+	}
+
+	if strings.Count(output, "11\n") != 2 {
+		T.Logf("\nTestSession | input after :quit was evaluated. got:\n%s", output)
+		T.Fail()
+	}
+}
@@ -1,50 +1,166 @@
 package smol
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 
-	"github.com/fabulousduck/smol/bytecode"
-
 	"github.com/fabulousduck/smol/ast"
+	"github.com/fabulousduck/smol/diag"
+	"github.com/fabulousduck/smol/errors"
+	"github.com/fabulousduck/smol/file"
+	"github.com/fabulousduck/smol/interpreter"
 	"github.com/fabulousduck/smol/ir"
 	"github.com/fabulousduck/smol/lexer"
 )
 
 //Smol : Defines the global attributes of the interpreter
+//Output is the path compiled ROMs are written to
+//HadError is set once a run reported an error, the command line exits non-zero when it is
 type Smol struct {
 	Tokens   []*lexer.Token
-	HadError bool //TODO: use this
+	HadError bool
+	Output   string
 }
 
 //NewSmol : Creates a new Smol instance
 func NewSmol() *Smol {
-	return new(Smol)
+	smol := new(Smol)
+	smol.Output = "ROM"
+	return smol
 }
 
 //RunFile : Interprets a given file
 func (smol *Smol) RunFile(filename string) {
-	file, err := ioutil.ReadFile(filename)
-	if err != nil {
-		panic(err)
+	if source, ok := smol.readFile(filename); ok {
+		smol.Run(source, filename)
 	}
-	smol.Run(string(file), filename)
-	if smol.HadError {
-		os.Exit(65)
+}
+
+//readFile reads the source in filename. a file that cannot be read is reported like any other error
+func (smol *Smol) readFile(filename string) (string, bool) {
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		smol.report(diag.List{errors.FileReadError(err.Error())}, filename, "")
+		return "", false
 	}
+	return string(source), true
 }
 
-//Run exectues a given script
+//Run compiles a given script into a ROM at smol.Output. files ending in .ir are read as IR
 func (smol *Smol) Run(sourceCode string, filename string) {
+	rom, diagnostics := Compile(sourceCode, filename, Options{})
+	smol.writeRom(rom, smol.report(diagnostics, filename, sourceCode))
+}
+
+func (smol *Smol) writeRom(rom []byte, hadError bool) {
+	if hadError {
+		return
+	}
+
+	if err := smol.saveRom(rom); err != nil {
+		smol.report(diag.List{errors.ROMWriteError(smol.Output, err.Error())}, smol.Output, "")
+	}
+}
+
+//saveRom writes rom to smol.Output
+func (smol *Smol) saveRom(rom []byte) error {
+	romFile, err := file.Create(smol.Output)
+	if err != nil {
+		return err
+	}
+	if err := file.Write(romFile, rom); err != nil {
+		romFile.Close()
+		return err
+	}
+	return romFile.Close()
+}
+
+/*
+report prints diagnostics once a stage is done with them and sets HadError
+when one of them is an error. returns whether there were errors.
+source is the source of filename so the lines with errors can be shown
+*/
+func (smol *Smol) report(diagnostics diag.List, filename string, source string) bool {
+	renderer := diag.NewRenderer(os.Stderr)
+	renderer.Sources[filename] = source
+	renderer.Render(diagnostics)
+	if diagnostics.HasErrors() {
+		smol.HadError = true
+	}
+	return smol.HadError
+}
+
+//EmitFile runs a given file through the pipeline up to stage and writes the output of that stage to out
+func (smol *Smol) EmitFile(filename string, stage string, out io.Writer) {
+	if source, ok := smol.readFile(filename); ok {
+		smol.Emit(source, filename, stage, out)
+	}
+}
+
+/*
+Emit runs a given script through the pipeline up to stage
+and writes the output of that stage to out so it can be inspected.
+
+stage is one of tokens, ast, ir or rom.
+rom writes the ROM file just like Run does
+*/
+func (smol *Smol) Emit(sourceCode string, filename string, stage string, out io.Writer) {
+	switch stage {
+	case "tokens", "ast", "ir":
+	case "rom":
+		smol.Run(sourceCode, filename)
+		return
+	default:
+		fmt.Fprintf(out, "unknown stage %s. expected one of tokens, ast, ir, rom\n", stage)
+		smol.HadError = true
+		return
+	}
+
 	l := lexer.NewLexer(filename, sourceCode)
 	l.Lex()
+	defer func() { smol.report(*l.Diagnostics, filename, sourceCode) }()
+	if stage == "tokens" {
+		for _, token := range l.Tokens {
+			fmt.Fprintf(out, "%d:%d %s %q\n", token.Line, token.Col, token.Type, token.Value)
+		}
+		return
+	}
+
 	p := ast.NewParser(filename, l.Tokens)
-	//We can ignore the second return value here as it is the amount of tokens consumed.
-	//We do not need this here
+	p.Diagnostics = l.Diagnostics
 	p.Ast, _ = p.Parse("")
+	if stage == "ast" {
+		fmt.Fprint(out, ast.Dump(p.Ast))
+		return
+	}
+
 	g := ir.NewGenerator(filename)
+	g.Diagnostics = l.Diagnostics
 	g.Generate(p.Ast)
-	bg := bytecode.Init(g, filename)
-	bg.CreateRom()
-	return
+	fmt.Fprint(out, g.Dump())
+}
+
+//InterpretFile runs a given file with the tree-walking interpreter instead of compiling it
+func (smol *Smol) InterpretFile(filename string) {
+	if source, ok := smol.readFile(filename); ok {
+		smol.Interpret(source, filename)
+	}
+}
+
+//Interpret executes a given script directly from its AST without generating a ROM
+func (smol *Smol) Interpret(sourceCode string, filename string) {
+	l := lexer.NewLexer(filename, sourceCode)
+	l.Lex()
+	p := ast.NewParser(filename, l.Tokens)
+	p.Diagnostics = l.Diagnostics
+	p.Ast, _ = p.Parse("")
+	if smol.report(*p.Diagnostics, filename, sourceCode) {
+		return
+	}
+	i := interpreter.NewInterpreter(filename)
+	i.Source = sourceCode
+	i.Run(p.Ast)
+	smol.HadError = i.HadError
 }
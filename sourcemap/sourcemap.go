@@ -0,0 +1,257 @@
+package sourcemap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+/*
+Map ties a ROM back to the program it was compiled from.
+It is written next to the ROM as ROM.map so debuggers,
+profilers and the disassembler can show the source of an address.
+
+Addresses are those of the chip-8 machine, so the first opcode of a ROM is at 0x200
+*/
+type Map struct {
+	File      string     `json:"file"`
+	Lines     []Line     `json:"lines"`
+	Variables []Variable `json:"variables"`
+	Functions []Function `json:"functions"`
+}
+
+/*
+Line says that the opcodes from Addr up to End were compiled from
+the source that starts at Line and Col and ends just before EndLine and EndCol.
+Lines are sorted by address and do not overlap
+*/
+type Line struct {
+	Addr    int `json:"addr"`
+	End     int `json:"end"`
+	Line    int `json:"line"`
+	Col     int `json:"col"`
+	EndLine int `json:"endLine"`
+	EndCol  int `json:"endCol"`
+}
+
+/*
+Variable is where a variable of the program is kept.
+Variables declared in a function and its parameters are named function.name, like inc.x.
+Register is -1 for variables in memory, Addr is -1 for variables in a register
+*/
+type Variable struct {
+	Name     string `json:"name"`
+	Register int    `json:"register"`
+	Addr     int    `json:"addr"`
+}
+
+//Function is a def of the program. its code runs from Start up to End
+type Function struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+//Lookup returns the line the opcode at addr was compiled from
+func (m *Map) Lookup(addr int) (Line, bool) {
+	i := sort.Search(len(m.Lines), func(i int) bool { return m.Lines[i].End > addr })
+	if i < len(m.Lines) && m.Lines[i].Addr <= addr {
+		return m.Lines[i], true
+	}
+	return Line{}, false
+}
+
+//Addresses returns the address of the first opcode of every stretch of code compiled from line
+func (m *Map) Addresses(line int) []int {
+	addresses := []int{}
+	for _, l := range m.Lines {
+		if l.Line == line {
+			addresses = append(addresses, l.Addr)
+		}
+	}
+	return addresses
+}
+
+//Function returns the function the code at addr is part of
+func (m *Map) Function(addr int) (Function, bool) {
+	for _, f := range m.Functions {
+		if addr >= f.Start && addr < f.End {
+			return f, true
+		}
+	}
+	return Function{}, false
+}
+
+//Variable looks up a variable by name
+func (m *Map) Variable(name string) (Variable, bool) {
+	for _, v := range m.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+//WriteJSON writes m as indented JSON
+func (m *Map) WriteJSON(w io.Writer) error {
+	body, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
+
+//magic starts every map in the binary format, the last byte is the version of the format
+var magic = []byte("SMAP\x01")
+
+/*
+WriteBinary writes m in the compact binary format.
+After the magic every number is a varint. strings are their length followed by their bytes.
+
+	file
+	count, then every line as addr and end relative to the end of the line before it, line, col, endLine, endCol
+	count, then every variable as name, register, addr
+	count, then every function as name, start, end
+*/
+func (m *Map) WriteBinary(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	out.Write(magic)
+	e := encoder{out}
+
+	e.string(m.File)
+	e.int(len(m.Lines))
+	previous := 0
+	for _, l := range m.Lines {
+		e.int(l.Addr - previous)
+		e.int(l.End - l.Addr)
+		e.int(l.Line)
+		e.int(l.Col)
+		e.int(l.EndLine)
+		e.int(l.EndCol)
+		previous = l.End
+	}
+
+	e.int(len(m.Variables))
+	for _, v := range m.Variables {
+		e.string(v.Name)
+		e.int(v.Register)
+		e.int(v.Addr)
+	}
+
+	e.int(len(m.Functions))
+	for _, f := range m.Functions {
+		e.string(f.Name)
+		e.int(f.Start)
+		e.int(f.End)
+	}
+	return out.Flush()
+}
+
+/*
+Read reads a map in either format.
+Maps that start with the magic of the binary format are read as binary, anything else as JSON
+*/
+func Read(r io.Reader) (*Map, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(Map)
+	if !bytes.HasPrefix(data, magic[:4]) {
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	if !bytes.HasPrefix(data, magic) {
+		return nil, fmt.Errorf("unsupported source map version %d", data[4])
+	}
+
+	d := &decoder{data: data[len(magic):]}
+	m.File = d.string()
+	m.Lines = make([]Line, d.count())
+	previous := 0
+	for i := range m.Lines {
+		l := &m.Lines[i]
+		l.Addr = previous + d.int()
+		l.End = l.Addr + d.int()
+		l.Line, l.Col, l.EndLine, l.EndCol = d.int(), d.int(), d.int(), d.int()
+		previous = l.End
+	}
+
+	m.Variables = make([]Variable, d.count())
+	for i := range m.Variables {
+		m.Variables[i] = Variable{Name: d.string(), Register: d.int(), Addr: d.int()}
+	}
+
+	m.Functions = make([]Function, d.count())
+	for i := range m.Functions {
+		m.Functions[i] = Function{Name: d.string(), Start: d.int(), End: d.int()}
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	return m, nil
+}
+
+type encoder struct {
+	out *bufio.Writer
+}
+
+func (e encoder) int(n int) {
+	var buf [binary.MaxVarintLen64]byte
+	e.out.Write(buf[:binary.PutVarint(buf[:], int64(n))])
+}
+
+func (e encoder) string(s string) {
+	e.int(len(s))
+	e.out.WriteString(s)
+}
+
+//decoder reads the binary format. after the first error every read returns zero and err is kept
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) int() int {
+	if d.err != nil {
+		return 0
+	}
+	n, size := binary.Varint(d.data)
+	if size <= 0 {
+		d.err = fmt.Errorf("source map is cut short")
+		return 0
+	}
+	d.data = d.data[size:]
+	return int(n)
+}
+
+//count reads the length of a list. lists can never be longer than what is left of the map
+func (d *decoder) count() int {
+	n := d.int()
+	if n < 0 || n > len(d.data) {
+		if d.err == nil {
+			d.err = fmt.Errorf("source map is corrupt")
+		}
+		return 0
+	}
+	return n
+}
+
+func (d *decoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
@@ -0,0 +1,60 @@
+package sourcemap
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var example = &Map{
+	File: "example.lo",
+	Lines: []Line{
+		{Addr: 0x200, End: 0x202, Line: 1, Col: 1, EndLine: 1, EndCol: 13},
+		{Addr: 0x204, End: 0x206, Line: 3, Col: 5, EndLine: 3, EndCol: 8},
+		{Addr: 0x206, End: 0x20C, Line: 2, Col: 1, EndLine: 4, EndCol: 4},
+	},
+	Variables: []Variable{{Name: "a", Register: 0, Addr: -1}, {Name: "b", Register: -1, Addr: 0xEA0}},
+	Functions: []Function{{Name: "inc", Start: 0x204, End: 0x208}},
+}
+
+func TestRoundTrip(T *testing.T) {
+	for _, format := range []string{"json", "binary"} {
+		var buf bytes.Buffer
+		if format == "json" {
+			example.WriteJSON(&buf)
+		} else {
+			example.WriteBinary(&buf)
+		}
+
+		m, err := Read(&buf)
+		if err != nil || !reflect.DeepEqual(m, example) {
+			T.Logf("\nTestRoundTrip | expected the %s map to read back the same. got %+v %v", format, m, err)
+			T.Fail()
+		}
+	}
+
+	var buf bytes.Buffer
+	example.WriteBinary(&buf)
+	if _, err := Read(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+		T.Logf("\nTestRoundTrip | expected a binary map that is cut short to be an error")
+		T.Fail()
+	}
+}
+
+func TestLookup(T *testing.T) {
+	tests := map[int]int{0x200: 1, 0x201: 1, 0x204: 3, 0x20A: 2}
+	for addr, line := range tests {
+		if l, ok := example.Lookup(addr); !ok || l.Line != line {
+			T.Logf("\nTestLookup | expected 0x%X to be on line %d. got %d", addr, line, l.Line)
+			T.Fail()
+		}
+	}
+	if _, ok := example.Lookup(0x202); ok {
+		T.Logf("\nTestLookup | expected 0x202 to have no line")
+		T.Fail()
+	}
+	if f, ok := example.Function(0x206); !ok || f.Name != "inc" {
+		T.Logf("\nTestLookup | expected 0x206 to be part of inc. got %+v", f)
+		T.Fail()
+	}
+}
@@ -0,0 +1,188 @@
+package chip8
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	//MemorySize is the amount of bytes of memory a chip-8 has
+	MemorySize = 4096
+	//ProgramStart is the address ROMs are loaded at. Everything before it is reserved for the interpreter
+	ProgramStart = 0x200
+	//ScreenWidth is the width of the display in pixels
+	ScreenWidth = 64
+	//ScreenHeight is the height of the display in pixels
+	ScreenHeight = 32
+	//StackSize is the amount of return addresses the stack can hold
+	StackSize = 16
+)
+
+/*
+Machine is a chip-8 virtual machine.
+It runs headless. Anything that wants to show the screen
+or feed it keys can do so through Screen and Keypad
+*/
+type Machine struct {
+	Memory     [MemorySize]byte
+	V          [16]byte
+	I          uint16
+	PC         uint16
+	Stack      [StackSize]uint16
+	SP         byte
+	DelayTimer byte
+	SoundTimer byte
+	Screen     [ScreenHeight][ScreenWidth]bool
+
+	Keypad Keypad
+	Clock  Clock
+	Rand   *rand.Rand
+
+	//Cycles is the amount of instructions executed so far
+	Cycles int
+	//Halted is set when the machine hits an endless jump to itself or an empty (0000) opcode
+	Halted bool
+	//DrawFlag is set when the screen changed. whoever draws the screen should clear it
+	DrawFlag bool
+	//Watch is called with every address an instruction reads or writes when it is set, so a debugger can watch memory
+	Watch func(addr uint16, write bool)
+}
+
+/*
+NewMachine creates a machine with the font loaded and the
+program counter at the start of program space.
+
+By default it uses an empty keypad, a clock that ticks every
+10 cycles and a random source with a fixed seed so runs are
+reproducible
+*/
+func NewMachine() *Machine {
+	m := new(Machine)
+	m.Keypad = new(KeyState)
+	m.Clock = NewCycleClock(10)
+	m.Rand = rand.New(rand.NewSource(1))
+	m.Reset()
+	return m
+}
+
+/*
+Reset clears all state of the machine except for its keypad, clock and random source
+*/
+func (m *Machine) Reset() {
+	m.Memory = [MemorySize]byte{}
+	m.V = [16]byte{}
+	m.Stack = [StackSize]uint16{}
+	m.Screen = [ScreenHeight][ScreenWidth]bool{}
+	m.I = 0
+	m.SP = 0
+	m.DelayTimer = 0
+	m.SoundTimer = 0
+	m.PC = ProgramStart
+	m.Cycles = 0
+	m.Halted = false
+	m.DrawFlag = false
+	copy(m.Memory[FontStart:], fontset[:])
+}
+
+//LoadROM copies a ROM into program space
+func (m *Machine) LoadROM(rom []byte) error {
+	if len(rom) > MemorySize-ProgramStart {
+		return fmt.Errorf("ROM of %d bytes does not fit in %d bytes of program space", len(rom), MemorySize-ProgramStart)
+	}
+	copy(m.Memory[ProgramStart:], rom)
+	return nil
+}
+
+//Opcode returns the opcode the program counter points at
+func (m *Machine) Opcode() uint16 {
+	return uint16(m.Memory[m.PC&0xFFF])<<8 | uint16(m.Memory[(m.PC+1)&0xFFF])
+}
+
+/*
+Step executes a single instruction and updates the timers
+*/
+func (m *Machine) Step() error {
+	if m.Halted {
+		return nil
+	}
+	if int(m.PC)+1 >= MemorySize {
+		return fmt.Errorf("program counter 0x%03X ran out of memory", m.PC)
+	}
+
+	opcode := m.Opcode()
+	m.PC += 2
+	if err := m.execute(opcode); err != nil {
+		return err
+	}
+	m.Cycles++
+
+	for ticks := m.Clock.Ticks(); ticks > 0; ticks-- {
+		if m.DelayTimer > 0 {
+			m.DelayTimer--
+		}
+		if m.SoundTimer > 0 {
+			m.SoundTimer--
+		}
+	}
+	return nil
+}
+
+/*
+Run steps the machine until it halts or maxCycles instructions have been executed.
+A maxCycles of 0 or less means there is no limit
+*/
+func (m *Machine) Run(maxCycles int) error {
+	for start := m.Cycles; !m.Halted; {
+		if maxCycles > 0 && m.Cycles-start >= maxCycles {
+			return nil
+		}
+		if err := m.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Pixel returns if the pixel at x, y is on
+func (m *Machine) Pixel(x int, y int) bool {
+	return m.Screen[y%ScreenHeight][x%ScreenWidth]
+}
+
+//ScreenString renders the screen as text with '#' for pixels that are on and '.' for pixels that are off
+func (m *Machine) ScreenString() string {
+	var screen strings.Builder
+	for y := 0; y < ScreenHeight; y++ {
+		for x := 0; x < ScreenWidth; x++ {
+			if m.Screen[y][x] {
+				screen.WriteByte('#')
+			} else {
+				screen.WriteByte('.')
+			}
+		}
+		screen.WriteByte('\n')
+	}
+	return screen.String()
+}
+
+/*
+WriteMemory writes a single byte of memory.
+All writes done by instructions go through here
+*/
+func (m *Machine) WriteMemory(addr uint16, value byte) {
+	if m.Watch != nil {
+		m.Watch(addr&0xFFF, true)
+	}
+	m.Memory[addr&0xFFF] = value
+}
+
+/*
+ReadMemory reads a single byte of memory.
+All reads done by instructions go through here, fetching opcodes does not
+*/
+func (m *Machine) ReadMemory(addr uint16) byte {
+	if m.Watch != nil {
+		m.Watch(addr&0xFFF, false)
+	}
+	return m.Memory[addr&0xFFF]
+}
@@ -0,0 +1,159 @@
+package chip8
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fabulousduck/smol"
+)
+
+func runROM(T *testing.T, rom []byte, maxCycles int) *Machine {
+	m := NewMachine()
+	if err := m.LoadROM(rom); err != nil {
+		T.Fatal(err)
+	}
+	if err := m.Run(maxCycles); err != nil {
+		T.Fatal(err)
+	}
+	return m
+}
+
+func TestArithmetic(T *testing.T) {
+	rom := []byte{
+		0x60, 0xFF, // LD V0, 0xFF
+		0x61, 0x02, // LD V1, 0x02
+		0x80, 0x14, // ADD V0, V1
+		0x62, 0x05, // LD V2, 0x05
+		0x63, 0x07, // LD V3, 0x07
+		0x82, 0x35, // SUB V2, V3
+		0x64, 0x81, // LD V4, 0x81
+		0x84, 0x0E, // SHL V4
+		0x12, 0x10, // JP 0x210
+	}
+	m := runROM(T, rom, 100)
+
+	expected := map[int]byte{0x0: 0x01, 0x2: 0xFE, 0x4: 0x02, 0xF: 0x01}
+	for register, value := range expected {
+		if m.V[register] != value {
+			T.Logf("\nTestArithmetic | V%X is 0x%02X. expected 0x%02X", register, m.V[register], value)
+			T.Fail()
+		}
+	}
+	if !m.Halted || m.PC != 0x210 {
+		T.Logf("\nTestArithmetic | expected the machine to halt on the jump to itself. PC is 0x%03X", m.PC)
+		T.Fail()
+	}
+}
+
+func TestCallAndReturn(T *testing.T) {
+	rom := []byte{
+		0x22, 0x06, // CALL 0x206
+		0x61, 0x01, // LD V1, 0x01
+		0x12, 0x04, // JP 0x204
+		0x60, 0x2A, // LD V0, 0x2A
+		0x00, 0xEE, // RET
+	}
+	m := runROM(T, rom, 100)
+
+	if m.V[0] != 0x2A || m.V[1] != 0x01 || m.SP != 0 {
+		T.Logf("\nTestCallAndReturn | V0: 0x%02X, V1: 0x%02X, SP: %d", m.V[0], m.V[1], m.SP)
+		T.Fail()
+	}
+}
+
+func TestBCDAndMemory(T *testing.T) {
+	rom := []byte{
+		0x60, 0xEA, // LD V0, 234
+		0xA3, 0x00, // LD I, 0x300
+		0xF0, 0x33, // LD B, V0
+		0xF2, 0x65, // LD V2, [I]
+	}
+	m := runROM(T, rom, 4)
+
+	if m.Memory[0x300] != 2 || m.Memory[0x301] != 3 || m.Memory[0x302] != 4 {
+		T.Logf("\nTestBCDAndMemory | BCD of 234 stored as %v", m.Memory[0x300:0x303])
+		T.Fail()
+	}
+	if m.V[0] != 2 || m.V[1] != 3 || m.V[2] != 4 {
+		T.Logf("\nTestBCDAndMemory | registers loaded as %v", m.V[0:3])
+		T.Fail()
+	}
+}
+
+func TestDrawAndCollision(T *testing.T) {
+	rom := []byte{
+		0x60, 0x3E, // LD V0, 62
+		0x61, 0x01, // LD V1, 1
+		0x62, 0x0A, // LD V2, 0xA
+		0xF2, 0x29, // LD F, V2
+		0xD0, 0x15, // DRW V0, V1, 5
+		0x63, 0x00, // LD V3, VF
+		0xD0, 0x15, // DRW V0, V1, 5
+	}
+	m := runROM(T, rom, 6)
+
+	//the top row of A is 0xF0. drawn at x 62 it wraps around to x 0 and 1
+	for _, x := range []int{62, 63, 0, 1} {
+		if !m.Pixel(x, 1) {
+			T.Logf("\nTestDrawAndCollision | expected pixel %d,1 to be on", x)
+			T.Fail()
+		}
+	}
+	if m.V[3] != 0 {
+		T.Logf("\nTestDrawAndCollision | first draw reported a collision")
+		T.Fail()
+	}
+
+	m.Run(1)
+	if m.V[0xF] != 1 || m.Pixel(62, 1) {
+		T.Logf("\nTestDrawAndCollision | drawing the same sprite twice did not erase it")
+		T.Fail()
+	}
+}
+
+func TestKeypadAndTimers(T *testing.T) {
+	rom := []byte{
+		0xF0, 0x0A, // LD V0, K
+		0x61, 0x03, // LD V1, 3
+		0xF1, 0x15, // LD DT, V1
+		0xF2, 0x07, // LD V2, DT
+		0x32, 0x00, // SE V2, 0
+		0x12, 0x06, // JP 0x206
+	}
+	m := NewMachine()
+	m.Clock = NewCycleClock(1)
+	m.LoadROM(rom)
+
+	m.Run(10)
+	if m.PC != 0x200 {
+		T.Logf("\nTestKeypadAndTimers | machine did not wait for a key. PC is 0x%03X", m.PC)
+		T.Fail()
+	}
+
+	m.Keypad.(*KeyState)[0xB] = true
+	m.Run(100)
+	if m.V[0] != 0xB || m.DelayTimer != 0 || m.PC != 0x20C {
+		T.Logf("\nTestKeypadAndTimers | V0: 0x%X, DT: %d, PC: 0x%03X", m.V[0], m.DelayTimer, m.PC)
+		T.Fail()
+	}
+}
+
+func TestCompiledPlot(T *testing.T) {
+	wd, _ := os.Getwd()
+	source, err := ioutil.ReadFile(wd + "/../../examples/plot.lo")
+	if err != nil {
+		T.Fatal(err)
+	}
+
+	rom, diagnostics := smol.Compile(string(source), "plot.lo", smol.Options{})
+	if len(diagnostics) > 0 {
+		T.Fatal(diagnostics)
+	}
+
+	m := runROM(T, rom, 1000)
+	if !m.Pixel(10, 10) || m.V[0] != 10 || m.V[1] != 10 {
+		T.Logf("\nTestCompiledPlot | expected a pixel at 10,10 with x and y in V0 and V1\n%s", m.ScreenString())
+		T.Fail()
+	}
+}
@@ -0,0 +1,74 @@
+package chip8
+
+import "time"
+
+/*
+Keypad reports the state of the 16 key hex keypad.
+Keys are numbered 0x0 through 0xF
+*/
+type Keypad interface {
+	IsPressed(key byte) bool
+}
+
+/*
+KeyState is a simple Keypad that can be set from code.
+This is what headless runs and tests use
+*/
+type KeyState [16]bool
+
+//IsPressed checks if key is currently held down
+func (k *KeyState) IsPressed(key byte) bool {
+	return k[key&0xF]
+}
+
+/*
+Clock tells the machine how many 60hz timer ticks have passed
+since the last time it was asked. It is asked once every cycle
+*/
+type Clock interface {
+	Ticks() int
+}
+
+/*
+CycleClock ticks once every CyclesPerTick cycles.
+It does not depend on wall time, so runs using it are deterministic
+*/
+type CycleClock struct {
+	CyclesPerTick int
+	cycles        int
+}
+
+//NewCycleClock creates a clock that ticks once every cyclesPerTick cycles
+func NewCycleClock(cyclesPerTick int) *CycleClock {
+	c := new(CycleClock)
+	c.CyclesPerTick = cyclesPerTick
+	return c
+}
+
+//Ticks implements Clock
+func (c *CycleClock) Ticks() int {
+	c.cycles++
+	if c.cycles < c.CyclesPerTick {
+		return 0
+	}
+	c.cycles = 0
+	return 1
+}
+
+//RealClock ticks at 60hz of wall time
+type RealClock struct {
+	last time.Time
+}
+
+//NewRealClock creates a clock that ticks at 60hz starting from now
+func NewRealClock() *RealClock {
+	return &RealClock{last: time.Now()}
+}
+
+//Ticks implements Clock
+func (c *RealClock) Ticks() int {
+	tick := time.Second / 60
+	ticks := int(time.Since(c.last) / tick)
+	c.last = c.last.Add(time.Duration(ticks) * tick)
+	return ticks
+}
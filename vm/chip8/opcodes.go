@@ -0,0 +1,220 @@
+package chip8
+
+import "fmt"
+
+/*
+execute runs a single opcode. The program counter already points
+at the next instruction when this is called
+
+The opcodes follow Cowgod's chip-8 technical reference.
+nnn: 12 bit address
+kk: 8 bit value
+x, y: 4 bit register indexes
+n: 4 bit value
+*/
+func (m *Machine) execute(opcode uint16) error {
+	nnn := opcode & 0x0FFF
+	kk := byte(opcode & 0x00FF)
+	n := byte(opcode & 0x000F)
+	x := (opcode & 0x0F00) >> 8
+	y := (opcode & 0x00F0) >> 4
+
+	switch opcode & 0xF000 {
+	case 0x0000:
+		switch opcode {
+		case 0x00E0: //CLS
+			m.Screen = [ScreenHeight][ScreenWidth]bool{}
+			m.DrawFlag = true
+		case 0x00EE: //RET
+			if m.SP == 0 {
+				return fmt.Errorf("stack underflow at 0x%03X", m.PC-2)
+			}
+			m.SP--
+			m.PC = m.Stack[m.SP]
+		case 0x0000:
+			//empty memory. there is nothing left to run
+			m.PC -= 2
+			m.Halted = true
+		default:
+			//SYS nnn. machine code routines are ignored by modern interpreters
+		}
+	case 0x1000: //JP nnn
+		if nnn == m.PC-2 {
+			//jumping to itself is the usual way to end a chip-8 program
+			m.PC = nnn
+			m.Halted = true
+			return nil
+		}
+		m.PC = nnn
+	case 0x2000: //CALL nnn
+		if int(m.SP) >= StackSize {
+			return fmt.Errorf("stack overflow at 0x%03X", m.PC-2)
+		}
+		m.Stack[m.SP] = m.PC
+		m.SP++
+		m.PC = nnn
+	case 0x3000: //SE Vx, kk
+		if m.V[x] == kk {
+			m.PC += 2
+		}
+	case 0x4000: //SNE Vx, kk
+		if m.V[x] != kk {
+			m.PC += 2
+		}
+	case 0x5000: //SE Vx, Vy
+		if n != 0 {
+			return unknownOpcode(opcode, m.PC-2)
+		}
+		if m.V[x] == m.V[y] {
+			m.PC += 2
+		}
+	case 0x6000: //LD Vx, kk
+		m.V[x] = kk
+	case 0x7000: //ADD Vx, kk
+		m.V[x] += kk
+	case 0x8000:
+		return m.executeArithmetic(opcode, x, y, n)
+	case 0x9000: //SNE Vx, Vy
+		if n != 0 {
+			return unknownOpcode(opcode, m.PC-2)
+		}
+		if m.V[x] != m.V[y] {
+			m.PC += 2
+		}
+	case 0xA000: //LD I, nnn
+		m.I = nnn
+	case 0xB000: //JP V0, nnn
+		m.PC = (nnn + uint16(m.V[0])) & 0xFFF
+	case 0xC000: //RND Vx, kk
+		m.V[x] = byte(m.Rand.Intn(256)) & kk
+	case 0xD000: //DRW Vx, Vy, n
+		m.draw(m.V[x], m.V[y], n)
+	case 0xE000:
+		switch kk {
+		case 0x9E: //SKP Vx
+			if m.Keypad.IsPressed(m.V[x] & 0xF) {
+				m.PC += 2
+			}
+		case 0xA1: //SKNP Vx
+			if !m.Keypad.IsPressed(m.V[x] & 0xF) {
+				m.PC += 2
+			}
+		default:
+			return unknownOpcode(opcode, m.PC-2)
+		}
+	case 0xF000:
+		return m.executeMisc(opcode, x, kk)
+	}
+	return nil
+}
+
+//executeArithmetic runs the 8xyn register to register opcodes
+func (m *Machine) executeArithmetic(opcode uint16, x uint16, y uint16, n byte) error {
+	switch n {
+	case 0x0: //LD Vx, Vy
+		m.V[x] = m.V[y]
+	case 0x1: //OR Vx, Vy
+		m.V[x] |= m.V[y]
+	case 0x2: //AND Vx, Vy
+		m.V[x] &= m.V[y]
+	case 0x3: //XOR Vx, Vy
+		m.V[x] ^= m.V[y]
+	case 0x4: //ADD Vx, Vy. VF = carry
+		sum := uint16(m.V[x]) + uint16(m.V[y])
+		m.V[x] = byte(sum)
+		m.V[0xF] = boolByte(sum > 0xFF)
+	case 0x5: //SUB Vx, Vy. VF = NOT borrow
+		notBorrow := m.V[x] >= m.V[y]
+		m.V[x] -= m.V[y]
+		m.V[0xF] = boolByte(notBorrow)
+	case 0x6: //SHR Vx. VF = shifted out bit
+		bit := m.V[x] & 0x1
+		m.V[x] >>= 1
+		m.V[0xF] = bit
+	case 0x7: //SUBN Vx, Vy. VF = NOT borrow
+		notBorrow := m.V[y] >= m.V[x]
+		m.V[x] = m.V[y] - m.V[x]
+		m.V[0xF] = boolByte(notBorrow)
+	case 0xE: //SHL Vx. VF = shifted out bit
+		bit := m.V[x] >> 7
+		m.V[x] <<= 1
+		m.V[0xF] = bit
+	default:
+		return unknownOpcode(opcode, m.PC-2)
+	}
+	return nil
+}
+
+//executeMisc runs the Fxkk opcodes
+func (m *Machine) executeMisc(opcode uint16, x uint16, kk byte) error {
+	switch kk {
+	case 0x07: //LD Vx, DT
+		m.V[x] = m.DelayTimer
+	case 0x0A: //LD Vx, K. waits by running this instruction again until a key is pressed
+		for key := byte(0); key < 16; key++ {
+			if m.Keypad.IsPressed(key) {
+				m.V[x] = key
+				return nil
+			}
+		}
+		m.PC -= 2
+	case 0x15: //LD DT, Vx
+		m.DelayTimer = m.V[x]
+	case 0x18: //LD ST, Vx
+		m.SoundTimer = m.V[x]
+	case 0x1E: //ADD I, Vx
+		m.I = (m.I + uint16(m.V[x])) & 0xFFF
+	case 0x29: //LD F, Vx
+		m.I = FontStart + uint16(m.V[x]&0xF)*5
+	case 0x33: //LD B, Vx
+		m.WriteMemory(m.I, m.V[x]/100)
+		m.WriteMemory(m.I+1, (m.V[x]/10)%10)
+		m.WriteMemory(m.I+2, m.V[x]%10)
+	case 0x55: //LD [I], Vx
+		for r := uint16(0); r <= x; r++ {
+			m.WriteMemory(m.I+r, m.V[r])
+		}
+	case 0x65: //LD Vx, [I]
+		for r := uint16(0); r <= x; r++ {
+			m.V[r] = m.ReadMemory(m.I + r)
+		}
+	default:
+		return unknownOpcode(opcode, m.PC-2)
+	}
+	return nil
+}
+
+/*
+draw XORs an n byte tall sprite from memory at I onto the screen at x, y.
+Pixels that go over the edge wrap around to the other side.
+VF is set when a pixel that was on gets turned off
+*/
+func (m *Machine) draw(x byte, y byte, n byte) {
+	m.V[0xF] = 0
+	for row := uint16(0); row < uint16(n); row++ {
+		sprite := m.ReadMemory(m.I + row)
+		for col := uint16(0); col < 8; col++ {
+			if sprite&(0x80>>col) == 0 {
+				continue
+			}
+			px := (uint16(x) + col) % ScreenWidth
+			py := (uint16(y) + row) % ScreenHeight
+			if m.Screen[py][px] {
+				m.V[0xF] = 1
+			}
+			m.Screen[py][px] = !m.Screen[py][px]
+		}
+	}
+	m.DrawFlag = true
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func unknownOpcode(opcode uint16, addr uint16) error {
+	return fmt.Errorf("unknown opcode %04X at 0x%03X", opcode, addr)
+}
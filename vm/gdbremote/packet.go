@@ -0,0 +1,96 @@
+package gdbremote
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+//interrupt is the byte a client sends to stop a machine that is running
+const interrupt = 0x03
+
+/*
+event is something the client sent. either a packet
+or an interrupt, which is not framed as a packet
+*/
+type event struct {
+	packet    string
+	interrupt bool
+	err       error
+}
+
+/*
+readEvents reads what the client sends until the connection closes
+and hands it to events. acks are dropped, the server does not resend packets.
+every packet is acked with + unless its checksum is wrong,
+then it is answered with - so the client sends it again
+*/
+func (s *Server) readEvents(r io.Reader, events chan<- event) {
+	defer close(events)
+	in := bufio.NewReader(r)
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			events <- event{err: err}
+			return
+		}
+		switch b {
+		case interrupt:
+			events <- event{interrupt: true}
+			continue
+		case '$':
+		default:
+			continue
+		}
+
+		body, err := in.ReadString('#')
+		if err != nil {
+			events <- event{err: err}
+			return
+		}
+		body = body[:len(body)-1]
+		sum := make([]byte, 2)
+		if _, err := io.ReadFull(in, sum); err != nil {
+			events <- event{err: err}
+			return
+		}
+
+		if expected, err := strconv.ParseUint(string(sum), 16, 8); err != nil || byte(expected) != checksum(body) {
+			s.writeRaw("-")
+			continue
+		}
+		if s.acks() {
+			s.writeRaw("+")
+		}
+		events <- event{packet: body}
+	}
+}
+
+//writePacket frames body as $body#checksum and sends it
+func (s *Server) writePacket(body string) error {
+	return s.writeRaw(fmt.Sprintf("$%s#%02x", body, checksum(body)))
+}
+
+func (s *Server) writeRaw(data string) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	_, err := io.WriteString(s.conn, data)
+	return err
+}
+
+//acks reports whether packets still have to be acked, which stops after QStartNoAckMode
+func (s *Server) acks() bool {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	return !s.noAck
+}
+
+//checksum is the sum of the bytes of a packet body modulo 256
+func checksum(body string) byte {
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum += body[i]
+	}
+	return sum
+}
@@ -0,0 +1,429 @@
+package gdbremote
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fabulousduck/smol/vm/chip8"
+)
+
+/*
+the registers in the order g and G send them and the numbers p and P use.
+every register is sent big endian, like chip-8 stores its opcodes
+
+	0-15  V0-VF  1 byte
+	16    I      2 bytes
+	17    PC     2 bytes
+	18    SP     1 byte
+	19    DT     1 byte
+	20    ST     1 byte
+*/
+const (
+	registerI = 16 + iota
+	registerPC
+	registerSP
+	registerDT
+	registerST
+	registerCount
+)
+
+//the signals stop replies report
+const (
+	sigint  = 2
+	sigill  = 4
+	sigtrap = 5
+)
+
+//watchpoint kinds, numbered like the Z packets that set them
+const (
+	watchWrite  = 2
+	watchRead   = 3
+	watchAccess = 4
+)
+
+//interruptCheck is how many instructions are executed between checks for an interrupt while continuing
+const interruptCheck = 256
+
+/*
+Server lets a debugger drive a chip-8 machine over a connection
+using a subset of the GDB remote serial protocol.
+
+It supports reading and writing registers and memory, software and hardware breakpoints,
+write, read and access watchpoints, single stepping, continuing and interrupting a machine that runs
+*/
+type Server struct {
+	Machine     *chip8.Machine
+	breakpoints map[uint16]bool
+	watchpoints map[uint16]int
+	//hit is the watchpoint the last instruction touched
+	hit       *watchHit
+	conn      io.ReadWriter
+	writeLock sync.Mutex
+	noAck     bool
+	pending   []event
+}
+
+type watchHit struct {
+	addr uint16
+	kind int
+}
+
+//NewServer creates a server for machine
+func NewServer(machine *chip8.Machine) *Server {
+	s := new(Server)
+	s.Machine = machine
+	s.breakpoints = make(map[uint16]bool)
+	s.watchpoints = make(map[uint16]int)
+	return s
+}
+
+/*
+Serve answers the packets that come in on conn until the client kills the machine,
+detaches or closes the connection. breakpoints and watchpoints are kept between sessions
+*/
+func (s *Server) Serve(conn io.ReadWriter) error {
+	s.conn = conn
+	s.noAck = false
+	s.pending = nil
+	events := make(chan event)
+	go s.readEvents(conn, events)
+	//whatever is left is read until the connection is closed so the reader can stop
+	defer func() {
+		go func() {
+			for range events {
+			}
+		}()
+	}()
+
+	for {
+		e, ok := s.next(events)
+		if !ok || e.err == io.EOF {
+			return nil
+		}
+		if e.err != nil {
+			return e.err
+		}
+		if e.interrupt {
+			//the machine only runs while continuing, there is nothing to stop
+			continue
+		}
+		//kill is the only packet that is not answered
+		if e.packet == "k" {
+			return nil
+		}
+
+		reply, done := s.handle(e.packet, events)
+		if err := s.writePacket(reply); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+//next returns the next event, those that came in while the machine was running first
+func (s *Server) next(events <-chan event) (event, bool) {
+	if len(s.pending) > 0 {
+		e := s.pending[0]
+		s.pending = s.pending[1:]
+		return e, true
+	}
+	e, ok := <-events
+	return e, ok
+}
+
+//handle answers a single packet. done is set when the session ends after the reply
+func (s *Server) handle(packet string, events <-chan event) (reply string, done bool) {
+	if packet == "" {
+		return "", false
+	}
+	args := packet[1:]
+	switch packet[0] {
+	case '?':
+		return s.stopReply(sigtrap), false
+	case 'g':
+		return hex.EncodeToString(s.registers()), false
+	case 'G':
+		data, err := hex.DecodeString(args)
+		if err != nil || len(data) != len(s.registers()) || !s.setRegisters(data) {
+			return "E01", false
+		}
+		return "OK", false
+	case 'p':
+		n, err := strconv.ParseUint(args, 16, 8)
+		if err != nil || n >= registerCount {
+			return "E01", false
+		}
+		return hex.EncodeToString(s.register(int(n))), false
+	case 'P':
+		parts := strings.SplitN(args, "=", 2)
+		if len(parts) != 2 {
+			return "E01", false
+		}
+		n, err := strconv.ParseUint(parts[0], 16, 8)
+		value, hexErr := hex.DecodeString(parts[1])
+		if err != nil || hexErr != nil || n >= registerCount || len(value) != len(s.register(int(n))) || !validRegister(int(n), value) {
+			return "E01", false
+		}
+		s.setRegister(int(n), value)
+		return "OK", false
+	case 'm':
+		addr, length, ok := addressLength(args)
+		if !ok {
+			return "E01", false
+		}
+		data := make([]byte, length)
+		for i := range data {
+			data[i] = s.Machine.Memory[(addr+i)&0xFFF]
+		}
+		return hex.EncodeToString(data), false
+	case 'M':
+		parts := strings.SplitN(args, ":", 2)
+		addr, length, ok := addressLength(parts[0])
+		if !ok || len(parts) != 2 {
+			return "E01", false
+		}
+		data, err := hex.DecodeString(parts[1])
+		if err != nil || len(data) != length {
+			return "E01", false
+		}
+		for i, b := range data {
+			s.Machine.Memory[(addr+i)&0xFFF] = b
+		}
+		return "OK", false
+	case 'Z', 'z':
+		return s.setPoint(packet[0] == 'Z', args), false
+	case 's':
+		if !s.resumeAt(args) {
+			return "E01", false
+		}
+		return s.stopReply(s.step()), false
+	case 'c':
+		if !s.resumeAt(args) {
+			return "E01", false
+		}
+		return s.cont(events), false
+	case 'H':
+		return "OK", false
+	case 'D':
+		return "OK", true
+	}
+
+	switch {
+	case strings.HasPrefix(packet, "qSupported"):
+		return "PacketSize=4000;QStartNoAckMode+", false
+	case packet == "QStartNoAckMode":
+		//the packet itself has been acked already, everything after it is not
+		s.writeLock.Lock()
+		s.noAck = true
+		s.writeLock.Unlock()
+		return "OK", false
+	case packet == "qAttached":
+		return "1", false
+	}
+	//an empty reply tells the client the packet is not supported
+	return "", false
+}
+
+//addressLength reads the ADDR,LENGTH of memory and breakpoint packets
+func addressLength(args string) (int, int, bool) {
+	parts := strings.Split(args, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	addr, err := strconv.ParseUint(parts[0], 16, 16)
+	length, lengthErr := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil || lengthErr != nil || addr >= chip8.MemorySize || length > chip8.MemorySize {
+		return 0, 0, false
+	}
+	return int(addr), int(length), true
+}
+
+/*
+setPoint handles Z and z packets, TYPE,ADDR,KIND.
+types 0 and 1 are breakpoints, 2 to 4 watch writes, reads and both
+of KIND bytes starting at ADDR
+*/
+func (s *Server) setPoint(set bool, args string) string {
+	parts := strings.SplitN(args, ",", 2)
+	if len(parts) != 2 {
+		return "E01"
+	}
+	addr, length, ok := addressLength(parts[1])
+	if !ok {
+		return "E01"
+	}
+
+	switch parts[0] {
+	case "0", "1":
+		if set {
+			s.breakpoints[uint16(addr)] = true
+		} else {
+			delete(s.breakpoints, uint16(addr))
+		}
+	case "2", "3", "4":
+		kind := int(parts[0][0] - '0')
+		for i := 0; i < length || i == 0; i++ {
+			if set {
+				s.watchpoints[uint16((addr+i)&0xFFF)] = kind
+			} else {
+				delete(s.watchpoints, uint16((addr+i)&0xFFF))
+			}
+		}
+	default:
+		return ""
+	}
+	return "OK"
+}
+
+//resumeAt moves the program counter to the address s and c can be given
+func (s *Server) resumeAt(args string) bool {
+	if args == "" {
+		return true
+	}
+	addr, err := strconv.ParseUint(args, 16, 16)
+	if err != nil || addr >= chip8.MemorySize {
+		return false
+	}
+	s.Machine.PC = uint16(addr)
+	return true
+}
+
+//step executes a single instruction and returns the signal the machine stops with
+func (s *Server) step() int {
+	s.hit = nil
+	if s.Machine.Halted {
+		return sigtrap
+	}
+
+	s.Machine.Watch = s.watch
+	err := s.Machine.Step()
+	s.Machine.Watch = nil
+	if err != nil {
+		return sigill
+	}
+	return sigtrap
+}
+
+/*
+cont runs the machine until it reaches a breakpoint, touches a watchpoint, halts
+or the client sends an interrupt. the instruction at the program counter
+is always executed, so continuing from a breakpoint does not stop at it again
+*/
+func (s *Server) cont(events <-chan event) string {
+	for i := 1; ; i++ {
+		signal := s.step()
+		if signal != sigtrap || s.Machine.Halted || s.hit != nil || s.breakpoints[s.Machine.PC] {
+			return s.stopReply(signal)
+		}
+
+		if i%interruptCheck == 0 {
+			select {
+			case e, ok := <-events:
+				if !ok || e.interrupt {
+					return s.stopReply(sigint)
+				}
+				s.pending = append(s.pending, e)
+			default:
+			}
+		}
+	}
+}
+
+//watch is hooked into the machine while it runs to see if an instruction touches a watchpoint
+func (s *Server) watch(addr uint16, write bool) {
+	kind, ok := s.watchpoints[addr]
+	if !ok || s.hit != nil {
+		return
+	}
+	if kind == watchAccess || (kind == watchWrite) == write {
+		s.hit = &watchHit{addr: addr, kind: kind}
+	}
+}
+
+/*
+stopReply tells the client why the machine stopped.
+W00 when it halted, T05 with the watchpoint when one was touched and Sxx with signal otherwise
+*/
+func (s *Server) stopReply(signal int) string {
+	if s.Machine.Halted {
+		return "W00"
+	}
+	if s.hit != nil && signal == sigtrap {
+		name := map[int]string{watchWrite: "watch", watchRead: "rwatch", watchAccess: "awatch"}[s.hit.kind]
+		return fmt.Sprintf("T%02x%s:%x;", signal, name, s.hit.addr)
+	}
+	return fmt.Sprintf("S%02x", signal)
+}
+
+//registers returns every register in the layout of the g packet
+func (s *Server) registers() []byte {
+	data := []byte{}
+	for n := 0; n < registerCount; n++ {
+		data = append(data, s.register(n)...)
+	}
+	return data
+}
+
+//setRegisters sets every register from data in the layout of the g packet. nothing is set when one of the values is out of range
+func (s *Server) setRegisters(data []byte) bool {
+	values := [][]byte{}
+	for n := 0; n < registerCount; n++ {
+		size := len(s.register(n))
+		if !validRegister(n, data[:size]) {
+			return false
+		}
+		values = append(values, data[:size])
+		data = data[size:]
+	}
+
+	for n, value := range values {
+		s.setRegister(n, value)
+	}
+	return true
+}
+
+//validRegister checks if value fits register n. SP can point anywhere from the bottom of the stack up to just past its top
+func validRegister(n int, value []byte) bool {
+	return n != registerSP || int(value[0]) <= chip8.StackSize
+}
+
+func (s *Server) register(n int) []byte {
+	m := s.Machine
+	switch n {
+	case registerI:
+		return []byte{byte(m.I >> 8), byte(m.I)}
+	case registerPC:
+		return []byte{byte(m.PC >> 8), byte(m.PC)}
+	case registerSP:
+		return []byte{m.SP}
+	case registerDT:
+		return []byte{m.DelayTimer}
+	case registerST:
+		return []byte{m.SoundTimer}
+	}
+	return []byte{m.V[n]}
+}
+
+func (s *Server) setRegister(n int, value []byte) {
+	m := s.Machine
+	switch n {
+	case registerI:
+		m.I = (uint16(value[0])<<8 | uint16(value[1])) & 0xFFF
+	case registerPC:
+		m.PC = (uint16(value[0])<<8 | uint16(value[1])) & 0xFFF
+	case registerSP:
+		m.SP = value[0]
+	case registerDT:
+		m.DelayTimer = value[0]
+	case registerST:
+		m.SoundTimer = value[0]
+	default:
+		m.V[n] = value[0]
+	}
+}
@@ -0,0 +1,158 @@
+package gdbremote
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabulousduck/smol/vm/chip8"
+)
+
+//client is the loopback side of a session, it sends packets the way gdb does
+type client struct {
+	T    *testing.T
+	conn net.Conn
+	in   *bufio.Reader
+}
+
+//serve starts a server for rom on a loopback port and connects to it
+func serve(T *testing.T, rom []byte) (*client, chan error) {
+	machine := chip8.NewMachine()
+	machine.LoadROM(rom)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		T.Fatalf("\nTestServer | %s", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		done <- NewServer(machine).Serve(conn)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		T.Fatalf("\nTestServer | %s", err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	return &client{T: T, conn: conn, in: bufio.NewReader(conn)}, done
+}
+
+//send sends a packet and returns the reply, checking it is acked and framed correctly
+func (c *client) send(packet string) string {
+	fmt.Fprintf(c.conn, "$%s#%02x", packet, checksum(packet))
+	if ack, _ := c.in.ReadByte(); ack != '+' {
+		c.T.Fatalf("\nTestServer | expected %s to be acked. got %q", packet, ack)
+	}
+	return c.reply()
+}
+
+func (c *client) reply() string {
+	if _, err := c.in.ReadString('$'); err != nil {
+		c.T.Fatalf("\nTestServer | expected a reply. got %s", err)
+	}
+	body, _ := c.in.ReadString('#')
+	body = strings.TrimSuffix(body, "#")
+	sum := make([]byte, 2)
+	io.ReadFull(c.in, sum)
+	if string(sum) != fmt.Sprintf("%02x", checksum(body)) {
+		c.T.Fatalf("\nTestServer | reply %s has checksum %s", body, sum)
+	}
+	return body
+}
+
+func (c *client) expect(packet string, reply string) {
+	if got := c.send(packet); got != reply {
+		c.T.Logf("\nTestServer | expected %s to be answered with %q. got %q", packet, reply, got)
+		c.T.Fail()
+	}
+}
+
+func TestServer(T *testing.T) {
+	c, done := serve(T, []byte{
+		0x60, 0x05, // 0x200 LD V0, 0x05
+		0xA3, 0x00, // 0x202 LD I, 0x300
+		0xF0, 0x55, // 0x204 LD [I], V0
+		0x70, 0x01, // 0x206 ADD V0, 0x01
+		0x12, 0x08, // 0x208 JP 0x208
+	})
+
+	c.expect("qSupported:swbreak+", "PacketSize=4000;QStartNoAckMode+")
+	c.expect("vMustReplyEmpty", "")
+	c.expect("?", "S05")
+	c.expect("Z0,204,2", "OK")
+	c.expect("Z2,300,1", "OK")
+
+	c.expect("c", "S05")
+	c.expect("g", "05000000000000000000000000000000"+"0300"+"0204"+"00"+"00"+"00")
+	c.expect("c", "T05watch:300;")
+	c.expect("p11", "0206")
+	c.expect("m300,2", "0500")
+	c.expect("M300,2:0708", "OK")
+	c.expect("m300,2", "0708")
+
+	c.expect("P0=2a", "OK")
+	c.expect("s", "S05")
+	c.expect("p0", "2b")
+	c.expect("p20", "E01")
+	c.expect("p12", "00")
+	c.expect("P12=10", "OK")
+	c.expect("P12=11", "E01")
+	c.expect("p12", "10")
+	c.expect("P12=00", "OK")
+	c.expect("G"+"00000000000000000000000000000000"+"0000"+"0000"+"11"+"00"+"00", "E01")
+	c.expect("p0", "2b")
+	c.expect("z0,204,2", "OK")
+	c.expect("c", "W00")
+
+	//a packet with a bad checksum is asked for again
+	fmt.Fprint(c.conn, "$g#00")
+	if nack, _ := c.in.ReadByte(); nack != '-' {
+		T.Logf("\nTestServer | expected a bad checksum to be answered with -. got %q", nack)
+		T.Fail()
+	}
+
+	fmt.Fprint(c.conn, "$k#6b")
+	c.in.ReadByte()
+	if err := <-done; err != nil {
+		T.Logf("\nTestServer | expected kill to end the session. got %s", err)
+		T.Fail()
+	}
+}
+
+func TestInterrupt(T *testing.T) {
+	c, done := serve(T, []byte{
+		0x12, 0x02, // 0x200 JP 0x202
+		0x12, 0x00, // 0x202 JP 0x200
+	})
+
+	c.expect("QStartNoAckMode", "OK")
+	fmt.Fprintf(c.conn, "$c#%02x", checksum("c"))
+	time.Sleep(10 * time.Millisecond)
+	c.conn.Write([]byte{interrupt})
+	if reply := c.reply(); reply != "S02" {
+		T.Logf("\nTestInterrupt | expected the running machine to stop with SIGINT. got %q", reply)
+		T.Fail()
+	}
+
+	//without acks the reply is the first thing that comes back
+	fmt.Fprintf(c.conn, "$D#%02x", checksum("D"))
+	if reply := c.reply(); reply != "OK" {
+		T.Logf("\nTestInterrupt | expected detach to be answered with OK. got %q", reply)
+		T.Fail()
+	}
+	if err := <-done; err != nil {
+		T.Logf("\nTestInterrupt | expected detach to end the session. got %s", err)
+		T.Fail()
+	}
+}
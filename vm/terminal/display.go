@@ -0,0 +1,64 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fabulousduck/smol/vm/chip8"
+)
+
+/*
+Render draws the screen of a machine using unicode half blocks.
+Every character holds two pixels stacked on top of each other,
+so the 64x32 screen takes up 64x16 characters
+*/
+func Render(m *chip8.Machine) string {
+	var screen strings.Builder
+	for y := 0; y < chip8.ScreenHeight; y += 2 {
+		for x := 0; x < chip8.ScreenWidth; x++ {
+			top, bottom := m.Screen[y][x], m.Screen[y+1][x]
+			switch {
+			case top && bottom:
+				screen.WriteString("█")
+			case top:
+				screen.WriteString("▀")
+			case bottom:
+				screen.WriteString("▄")
+			default:
+				screen.WriteString(" ")
+			}
+		}
+		screen.WriteString("\r\n")
+	}
+	return screen.String()
+}
+
+/*
+Display redraws a machine screen in place on a terminal
+*/
+type Display struct {
+	out io.Writer
+}
+
+//NewDisplay creates a display that draws to out
+func NewDisplay(out io.Writer) *Display {
+	d := new(Display)
+	d.out = out
+	return d
+}
+
+//Clear clears the terminal and hides the cursor
+func (d *Display) Clear() {
+	fmt.Fprint(d.out, "\x1b[2J\x1b[?25l")
+}
+
+//Draw moves the cursor to the top left and draws the screen over the previous frame
+func (d *Display) Draw(m *chip8.Machine) {
+	fmt.Fprint(d.out, "\x1b[H"+Render(m))
+}
+
+//Close shows the cursor again
+func (d *Display) Close() {
+	fmt.Fprint(d.out, "\x1b[?25h")
+}
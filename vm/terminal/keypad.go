@@ -0,0 +1,111 @@
+package terminal
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+/*
+keymap maps the keyboard to the 16 key hex keypad
+using the usual layout
+
+	1 2 3 C        1 2 3 4
+	4 5 6 D   <-   q w e r
+	7 8 9 E        a s d f
+	A 0 B F        z x c v
+*/
+var keymap = map[byte]byte{
+	'1': 0x1, '2': 0x2, '3': 0x3, '4': 0xC,
+	'q': 0x4, 'w': 0x5, 'e': 0x6, 'r': 0xD,
+	'a': 0x7, 's': 0x8, 'd': 0x9, 'f': 0xE,
+	'z': 0xA, 'x': 0x0, 'c': 0xB, 'v': 0xF,
+}
+
+/*
+keyHoldFrames is how many frames a key stays pressed after it was typed.
+Terminals do not report key releases, so this is the best we can do
+*/
+const keyHoldFrames = 6
+
+/*
+Keypad is a chip8.Keypad fed by a terminal in raw mode.
+Escape and ctrl-c close the Quit channel
+*/
+type Keypad struct {
+	Quit    chan struct{}
+	mutex   sync.Mutex
+	pressed [16]int
+}
+
+//NewKeypad creates a keypad and starts reading keys from in
+func NewKeypad(in io.Reader) *Keypad {
+	k := new(Keypad)
+	k.Quit = make(chan struct{})
+	go k.read(in)
+	return k
+}
+
+func (k *Keypad) read(in io.Reader) {
+	buffer := make([]byte, 1)
+	for {
+		if _, err := in.Read(buffer); err != nil {
+			close(k.Quit)
+			return
+		}
+		char := buffer[0]
+		if char == 0x1B || char == 0x03 {
+			close(k.Quit)
+			return
+		}
+		if key, ok := keymap[strings.ToLower(string(char))[0]]; ok {
+			k.mutex.Lock()
+			k.pressed[key] = keyHoldFrames
+			k.mutex.Unlock()
+		}
+	}
+}
+
+//IsPressed implements chip8.Keypad
+func (k *Keypad) IsPressed(key byte) bool {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	return k.pressed[key&0xF] > 0
+}
+
+//Frame must be called once every frame so held keys get released
+func (k *Keypad) Frame() {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	for key := range k.pressed {
+		if k.pressed[key] > 0 {
+			k.pressed[key]--
+		}
+	}
+}
+
+/*
+MakeRaw puts the terminal on stdin in raw mode so keys can be read
+as they are typed. The returned function restores the terminal
+*/
+func MakeRaw() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(strings.TrimSpace(state))
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}